@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.azc.ext.hp.com/hp-business-platform/lib-core-go/pkg/v1/test"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+func TestEnvRef(t *testing.T) {
+	RegisterFailHandlerWithT(t, Fail)
+	RunSpecsWithDefaultAndCustomReporters(t, "EnvRef test", test.LoadCustomReporters("../../test_cmd_envref.xml"))
+}
+
+var _ = Describe("EnvRef", func() {
+	var r *reference
+	BeforeEach(func() {
+		// Reading the descriptions from the source takes a while, so the reference is only built once.
+		if r == nil {
+			loadConfigs([]string{"BACKEND"}, []string{"USERS"})
+			r = newReference(config.Loaded())
+		}
+	})
+
+	It("Lists the variables of all Providers", func() {
+		for _, prefix := range []string{
+			"CONFIG", "STACK", "LOGRUS", "APP", "PROBES", "PROMETHEUS", "STATUS", "PPROF", "ADMIN", "JAEGER", "MONGODB", "MIGRATIONS",
+			"NATS", "GRPC", "GRPC_PAYLOAD_LOG", "GRPC_GATEWAY", "GRAPHQL", "JWT", "AUTHN", "AUTHZ", "GRPC_RATELIMIT", "BACKEND", "FIT_STATION", "USERS",
+		} {
+			Expect(r.prefixes).To(ContainElement(prefix))
+		}
+		Expect(r.prefixes[0]).To(Equal("CONFIG"))
+	})
+	It("Renders Markdown tables", func() {
+		out := &bytes.Buffer{}
+		Expect(r.markdown(out)).To(Succeed())
+		Expect(out.String()).To(ContainSubstring("### PROBES\n"))
+		Expect(out.String()).To(ContainSubstring("| PROBES_PORT | int | 8000 | Port on which to start the HTTP service. Between 0 and 65535. |"))
+		Expect(out.String()).To(ContainSubstring("| PROBES_READ_TIMEOUT | int (seconds) | 30 |"))
+		Expect(out.String()).To(ContainSubstring("| PROBES_TLS_CERT_FILE | string |"))
+		Expect(out.String()).To(ContainSubstring("| BACKEND_TARGET_URL | string | http://localhost:8080 |"))
+		Expect(out.String()).To(ContainSubstring("| USERS_PORT | int | 3000 |"))
+		Expect(out.String()).To(ContainSubstring("| STACK_SHUTDOWN_TIMEOUT | int (seconds) | 25 |"))
+		Expect(out.String()).To(ContainSubstring("| AUTHZ_SERVICE_HOST | string | hpbp.hpbp.io |"))
+	})
+	It("Renders a JSON schema", func() {
+		out := &bytes.Buffer{}
+		Expect(r.jsonSchema(out)).To(Succeed())
+
+		var schema struct {
+			Properties map[string]map[string]interface{}
+		}
+		Expect(json.Unmarshal(out.Bytes(), &schema)).To(Succeed())
+		Expect(schema.Properties["PROBES_PORT"]).To(Equal(map[string]interface{}{
+			"type":        "integer",
+			"default":     8000.0,
+			"minimum":     0.0,
+			"maximum":     65535.0,
+			"description": "Port on which to start the HTTP service.",
+		}))
+		Expect(schema.Properties["GRPC_LOG_PAYLOAD"]).To(HaveKeyWithValue("default", false))
+		Expect(schema.Properties["MONGODB_PASSWORD"]).To(HaveKeyWithValue("writeOnly", true))
+	})
+	It("Renders a sample .env file", func() {
+		out := &bytes.Buffer{}
+		Expect(r.dotEnv(out)).To(Succeed())
+		Expect(out.String()).To(ContainSubstring("\n# Port on which to start the HTTP service. Between 0 and 65535.\nPROBES_PORT=8000\n"))
+		Expect(out.String()).To(ContainSubstring("\n# MONGODB_PASSWORD=\n"))
+		Expect(out.String()).To(ContainSubstring("\n# PROBES_SOCKET_NAME=\n"))
+	})
+})
@@ -0,0 +1,123 @@
+// Command envref generates the reference of the environment variables accepted by the Providers of this library.
+//
+// It loads the configuration of every Provider (see config.Loaded()) and reads the descriptions of the settings from the comments of their fields,
+// so it has to run where the source of the library can be found (e.g. from the root of a service using it, with `go run`):
+//
+//	go run github.azc.ext.hp.com/hp-business-platform/lib-provider-go/cmd/envref -format markdown -o ENVIRONMENT.md
+//	go run github.azc.ext.hp.com/hp-business-platform/lib-provider-go/cmd/envref -format json -o env.schema.json
+//	go run github.azc.ext.hp.com/hp-business-platform/lib-provider-go/cmd/envref -format env -proxy BACKEND -connection USERS -o .env.sample
+//
+// Prefixed Providers (Proxy and GRPC Connection) are only listed for the prefixes given by the -proxy and -connection flags.
+// Defaults are read from the tags of the settings, but some of them depend on the environment (e.g. MONGODB_HOST is only used without MONGODB_URI),
+// so the command should run without the variables of the service set.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/middleware/authentication"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/middleware/authorization"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/middleware/jwt"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/middleware/ratelimit"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/admin"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/app"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/graphql"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/grpc"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/grpc/connection"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/grpc/gateway"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/jaeger"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/logrus"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/migrate"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/mongodb"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/nats"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/pprof"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/probes"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/prometheus"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/proxy"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/status"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/stack"
+	"io"
+	"os"
+	"strings"
+)
+
+func main() {
+	format := flag.String("format", "markdown", "Output format: markdown, json (JSON schema) or env (sample .env file)")
+	output := flag.String("o", "", "File to write to, instead of stdout")
+	proxies := flag.String("proxy", "", "Comma separated prefixes of the Proxy Providers (e.g. BACKEND)")
+	connections := flag.String("connection", "", "Comma separated prefixes of the GRPC Connection Providers (e.g. USERS)")
+	flag.Parse()
+
+	loadConfigs(split(*proxies), split(*connections))
+	if err := write(newReference(config.Loaded()), *format, *output); err != nil {
+		fmt.Fprintf(os.Stderr, "envref: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// Loads the configuration of every Provider, so their settings are listed by config.Loaded().
+func loadConfigs(proxies, connections []string) {
+	stack.NewConfigFromEnv()
+	logrus.NewConfigFromEnv()
+	app.NewConfigFromEnv()
+	probes.NewConfigFromEnv()
+	prometheus.NewConfigFromEnv()
+	status.NewConfigFromEnv()
+	pprof.NewConfigFromEnv()
+	admin.NewConfigFromEnv()
+	jaeger.NewConfigFromEnv()
+	mongodb.NewConfigFromEnv()
+	migrate.NewConfigFromEnv()
+	nats.NewConfigFromEnv()
+	grpc.NewConfigFromEnv()
+	gateway.NewConfigFromEnv()
+	graphql.NewConfigFromEnv()
+	jwt.NewConfigFromEnv()
+	authentication.NewConfigFromEnv()
+	authorization.NewConfigFromEnv()
+	ratelimit.NewConfigFromEnv()
+	for _, prefix := range proxies {
+		proxy.NewConfigFromEnv(prefix)
+	}
+	for _, prefix := range connections {
+		connection.NewConfigFromEnv(prefix)
+	}
+}
+
+func write(r *reference, format, output string) error {
+	var render func(io.Writer) error
+	switch format {
+	case "markdown":
+		render = r.markdown
+	case "json":
+		render = r.jsonSchema
+	case "env":
+		render = r.dotEnv
+	default:
+		return fmt.Errorf("unsupported format %s", format)
+	}
+
+	if output == "" {
+		return render(os.Stdout)
+	}
+	file, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if err := render(file); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func split(prefixes string) []string {
+	var result []string
+	for _, prefix := range strings.Split(prefixes, ",") {
+		if prefix = strings.ToUpper(strings.TrimSpace(prefix)); prefix != "" {
+			result = append(result, prefix)
+		}
+	}
+	return result
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Environment variable of a setting, along with the comment of its field.
+type variable struct {
+	config.Variable
+	Description string
+}
+
+// Reference of the environment variables, grouped by prefix in the order they were loaded.
+type reference struct {
+	prefixes  []string
+	variables map[string][]variable
+}
+
+// Builds the reference of the given variables, reading their descriptions from the source of their configuration structs.
+func newReference(variables []config.Variable) *reference {
+	r := &reference{variables: map[string][]variable{}}
+	comments := &comments{structs: map[reflect.Type]map[string]string{}}
+	for _, v := range variables {
+		if _, ok := r.variables[v.Prefix]; !ok {
+			r.prefixes = append(r.prefixes, v.Prefix)
+		}
+		r.variables[v.Prefix] = append(r.variables[v.Prefix], variable{
+			Variable:    v,
+			Description: comments.field(v.Struct, v.Field),
+		})
+	}
+	return r
+}
+
+// Writes the reference as Markdown tables, like the ones of the README.
+func (r *reference) markdown(w io.Writer) error {
+	p := &printer{w: w}
+	p.printf("# Environment variables\n\n")
+	p.printf("Any variable can also be read from a file, by setting the path of the file in the variable suffixed by `_FILE` (e.g. MONGODB_PASSWORD_FILE).\n")
+	for _, prefix := range r.prefixes {
+		p.printf("\n### %s\n\n", prefix)
+		p.printf("| ENV key | ENV value | Default value | Description |\n")
+		p.printf("| --- | --- | --- | --- |\n")
+		for _, v := range r.variables[prefix] {
+			p.printf("| %s | %s | %s | %s |\n", v.Env, valueType(v.Type), escape(v.Default), escape(v.details()))
+		}
+	}
+	return p.err
+}
+
+// Writes the reference as a JSON schema of an object holding the variables.
+func (r *reference) jsonSchema(w io.Writer) error {
+	properties := map[string]interface{}{}
+	required := []string{}
+	for _, prefix := range r.prefixes {
+		for _, v := range r.variables[prefix] {
+			property := map[string]interface{}{"type": schemaType(v.Type)}
+			if v.Description != "" {
+				property["description"] = v.Description
+			}
+			if v.HasDefault {
+				property["default"] = schemaValue(v.Type, v.Default)
+			}
+			if v.Min != "" {
+				property["minimum"] = schemaValue(durationType, v.Min)
+			}
+			if v.Max != "" {
+				property["maximum"] = schemaValue(durationType, v.Max)
+			}
+			if v.Secret {
+				property["writeOnly"] = true
+			}
+			properties[v.Env] = property
+			if v.Required && !v.HasDefault {
+				required = append(required, v.Env)
+			}
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(map[string]interface{}{
+		"$schema":    "http://json-schema.org/draft-07/schema#",
+		"title":      "Environment variables",
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	})
+}
+
+// Writes the reference as a sample .env file. Variables without a default are commented out, unless they are required.
+func (r *reference) dotEnv(w io.Writer) error {
+	p := &printer{w: w}
+	for i, prefix := range r.prefixes {
+		if i > 0 {
+			p.printf("\n")
+		}
+		p.printf("# %s\n", prefix)
+		for _, v := range r.variables[prefix] {
+			if details := v.details(); details != "" {
+				p.printf("# %s\n", details)
+			}
+			if !v.HasDefault && !v.Required {
+				p.printf("# ")
+			}
+			p.printf("%s=%s\n", v.Env, v.Default)
+		}
+	}
+	return p.err
+}
+
+// Returns the description of the variable, followed by its constraints.
+func (v variable) details() string {
+	details := []string{}
+	if v.Description != "" {
+		details = append(details, strings.TrimSuffix(v.Description, ".")+".")
+	}
+	if v.Required {
+		details = append(details, "Required.")
+	}
+	switch {
+	case v.Min != "" && v.Max != "":
+		details = append(details, fmt.Sprintf("Between %s and %s.", v.Min, v.Max))
+	case v.Min != "":
+		details = append(details, fmt.Sprintf("At least %s.", v.Min))
+	case v.Max != "":
+		details = append(details, fmt.Sprintf("At most %s.", v.Max))
+	}
+	if v.Secret {
+		details = append(details, fmt.Sprintf("Secret, preferably read from a file (see %s_FILE).", v.Env))
+	}
+	return strings.Join(details, " ")
+}
+
+// Describes the type of a setting, like the README does.
+func valueType(t reflect.Type) string {
+	if t == durationType {
+		return "int (seconds)"
+	}
+	if reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return "string"
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "int"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	case reflect.Slice:
+		return "string (comma separated)"
+	}
+	return "string"
+}
+
+func schemaType(t reflect.Type) string {
+	switch valueType(t) {
+	case "bool":
+		return "boolean"
+	case "int":
+		return "integer"
+	case "float", "int (seconds)":
+		return "number"
+	}
+	return "string"
+}
+
+// Converts a value of a tag to the JSON type of the setting, keeping it as a string if it can't be converted.
+func schemaValue(t reflect.Type, value string) interface{} {
+	switch schemaType(t) {
+	case "boolean":
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	case "integer":
+		if parsed, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return parsed
+		}
+	case "number":
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			return parsed
+		}
+	}
+	return value
+}
+
+func escape(value string) string {
+	return strings.ReplaceAll(value, "|", `\|`)
+}
+
+// Writes formatted text, keeping the first error.
+type printer struct {
+	w   io.Writer
+	err error
+}
+
+func (p *printer) printf(format string, args ...interface{}) {
+	if p.err == nil {
+		_, p.err = fmt.Fprintf(p.w, format, args...)
+	}
+}
+
+// Comments of the fields of configuration structs, read from their source.
+type comments struct {
+	structs map[reflect.Type]map[string]string
+}
+
+// Returns the comment of a field, or an empty string if the source of its struct can't be found.
+func (c *comments) field(structType reflect.Type, field string) string {
+	fields, ok := c.structs[structType]
+	if !ok {
+		fields = structComments(structType)
+		c.structs[structType] = fields
+	}
+	return fields[field]
+}
+
+// Parses the package declaring the struct, returning the comments of its fields by name.
+func structComments(structType reflect.Type) map[string]string {
+	fields := map[string]string{}
+	wd, _ := os.Getwd()
+	pkg, err := build.Import(structType.PkgPath(), wd, build.FindOnly)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "envref: no descriptions for %s: %s\n", structType, err)
+		return fields
+	}
+	packages, err := parser.ParseDir(token.NewFileSet(), pkg.Dir, nil, parser.ParseComments)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "envref: no descriptions for %s: %s\n", structType, err)
+		return fields
+	}
+
+	for _, p := range packages {
+		for _, file := range p.Files {
+			ast.Inspect(file, func(node ast.Node) bool {
+				spec, ok := node.(*ast.TypeSpec)
+				if !ok || spec.Name.Name != structType.Name() {
+					return true
+				}
+				if s, ok := spec.Type.(*ast.StructType); ok {
+					for _, f := range s.Fields.List {
+						comment := f.Comment
+						if comment == nil {
+							comment = f.Doc
+						}
+						for _, name := range f.Names {
+							if comment != nil {
+								fields[name.Name] = strings.Join(strings.Fields(comment.Text()), " ")
+							}
+						}
+					}
+				}
+				return false
+			})
+		}
+	}
+	return fields
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/examples/ping/server"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/admin"
 	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/app"
 	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/grpc"
 	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/grpc/gateway"
@@ -41,6 +42,11 @@ func main() {
 	probesProvider := probes.New(probesConfig, appProvider)
 	st.MustInit(probesProvider)
 
+	// Serves the probes, metrics and profiling data on a single port, if enabled.
+	adminConfig := admin.NewConfigFromEnv()
+	adminProvider := admin.New(adminConfig, probesProvider, prometheusProvider, pprofProvider)
+	st.MustInit(adminProvider)
+
 	grpcServerConfig := grpc.NewConfigFromEnv()
 	grpcServerProvider := grpc.New(grpcServerConfig)
 	st.MustInit(grpcServerProvider)
@@ -28,6 +28,11 @@ func NewPingService(grpcServerProvider *grpc.Server, grpcGatewayProvider *gatewa
 	}
 }
 
+// Dependencies ...
+func (s *PingService) Dependencies() []provider.Provider {
+	return []provider.Provider{s.grpcServerProvider, s.grpcGatewayProvider}
+}
+
 // Init ...
 func (s *PingService) Init() error {
 	gen.RegisterPingServiceServer(s.grpcServerProvider.Server, s)
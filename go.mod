@@ -9,6 +9,7 @@ require (
 	github.azc.ext.hp.com/hp-business-platform/lib-hpbp-rest-go v0.0.0-20200605084432-54bcc730f055
 	github.com/dgrijalva/jwt-go v3.2.0+incompatible
 	github.com/friendsofgo/graphiql v0.2.2
+	github.com/fsnotify/fsnotify v1.4.9
 	github.com/go-openapi/strfmt v0.19.5
 	github.com/gogo/gateway v1.1.0
 	github.com/gogo/googleapis v1.3.0
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var resultType = reflect.TypeOf(Result{})
+
+// Result of loading a configuration.
+// Embedded by configurations, so they keep the errors of loading them (see Load()) until the Stack checks them (see Err()).
+type Result struct {
+	err *Error
+}
+
+func (r *Result) result() *Result {
+	return r
+}
+
+// Invalid setting.
+type FieldError struct {
+	Env   string // Name of the environment variable (e.g. PROBES_PORT), key in the configuration file (e.g. probes.port), or the prefix for errors returned by Validate().
+	Value string // Invalid value, redacted for secret settings.
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	if e.Env == "" {
+		return e.Err.Error()
+	}
+	if e.Value == "" {
+		return fmt.Sprintf("%s: %s", e.Env, e.Err)
+	}
+	return fmt.Sprintf("%s=%q: %s", e.Env, e.Value, e.Err)
+}
+
+// Returns the original error, so errors.Is() and errors.As() can be used on a FieldError.
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Error listing every invalid setting of one or more configurations.
+type Error struct {
+	Errors []*FieldError
+}
+
+func (e *Error) add(env, value string, secret bool, err error) {
+	if secret && value != "" {
+		value = redacted
+	} else {
+		value = Redact(value)
+	}
+	e.Errors = append(e.Errors, &FieldError{Env: env, Value: value, Err: err})
+}
+
+func (e *Error) Error() string {
+	if len(e.Errors) == 1 {
+		return "invalid configuration: " + e.Errors[0].Error()
+	}
+
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%d invalid settings: %s", len(e.Errors), strings.Join(messages, "; "))
+}
+
+// Adds the errors of another configuration, ignoring the ones that were already listed (e.g. when configurations share an invalid file).
+func (e *Error) merge(other *Error) {
+	if other == nil {
+		return
+	}
+	for _, err := range other.Errors {
+		duplicate := false
+		for _, existing := range e.Errors {
+			if existing.Error() == err.Error() {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			e.Errors = append(e.Errors, err)
+		}
+	}
+}
+
+// Returns an *Error listing the invalid settings found when the configuration pointed to by target was loaded (see Load()),
+// including the ones of the configurations nested in it (e.g. its TLS settings), or nil if they are all valid.
+// Only configurations embedding a Result keep their errors.
+func Err(target interface{}) error {
+	errs := &Error{}
+	collect(reflect.ValueOf(target), errs, map[uintptr]bool{})
+	if len(errs.Errors) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// Keeps additional errors on the configuration pointed to by target if it embeds a Result, so they are reported by Err() as well.
+// Used when a configuration is built from settings loaded separately (e.g. fallback settings).
+func Keep(target interface{}, err error) {
+	r, ok := target.(interface{ result() *Result })
+	if !ok {
+		return
+	}
+	if r.result().err != nil {
+		err = Merge(r.result().err, err)
+	}
+	if merged, ok := Merge(err).(*Error); ok {
+		r.result().err = merged
+	}
+}
+
+// Merges the errors of several configurations (see Err()) into a single *Error, or returns nil if they are all nil.
+// Settings listed by more than one of them are only listed once.
+func Merge(errs ...error) error {
+	merged := &Error{}
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		var configErr *Error
+		if !errors.As(err, &configErr) {
+			configErr = &Error{Errors: []*FieldError{{Err: err}}}
+		}
+		merged.merge(configErr)
+	}
+	if len(merged.Errors) == 0 {
+		return nil
+	}
+	return merged
+}
+
+// Collects the errors kept by the configuration and by the configurations in its exported fields.
+func collect(v reflect.Value, errs *Error, visited map[uintptr]bool) {
+	switch v.Kind() {
+	case reflect.Interface:
+		if !v.IsNil() {
+			collect(v.Elem(), errs, visited)
+		}
+	case reflect.Ptr:
+		if v.IsNil() || visited[v.Pointer()] {
+			return
+		}
+		visited[v.Pointer()] = true
+		collect(v.Elem(), errs, visited)
+	case reflect.Struct:
+		if v.CanAddr() {
+			if r, ok := v.Addr().Interface().(interface{ result() *Result }); ok && r.result().err != nil {
+				errs.merge(r.result().err)
+			}
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if field := v.Type().Field(i); field.PkgPath == "" && field.Type != resultType {
+				collect(v.Field(i), errs, visited)
+			}
+		}
+	}
+}
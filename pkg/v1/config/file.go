@@ -0,0 +1,160 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Settings of the configuration file, which can only be set through environment variables.
+type fileConfig struct {
+	Path        string        `env:"FILE"`                             // Path to a YAML or JSON configuration file (e.g. a mounted ConfigMap). Environment variables take precedence over its settings.
+	Reload      bool          `env:"RELOAD" default:"true"`            // Whether or not to watch the configuration file and the secret files for changes.
+	ReloadDelay time.Duration `env:"RELOAD_DELAY" default:"1" min:"0"` // Delay between a change of a watched file and reloading it, so files replaced in several steps (e.g. a ConfigMap update) are complete.
+}
+
+// Configuration file, read when the first configuration is loaded.
+var file = struct {
+	sync.RWMutex
+	loaded  bool
+	config  fileConfig
+	err     error  // Invalid settings of the configuration file, reported with the errors of every configuration loaded (see Load()).
+	content []byte // Content of the file when it was last checked, so invalid content is only reported once.
+	values  map[string]interface{}
+}{}
+
+// Returns the settings of the configuration file, reading it first if needed.
+// Also returns an *Error if the settings of the configuration file are invalid, or if it can't be read.
+func fileValues() (map[string]interface{}, error) {
+	file.Lock()
+	defer file.Unlock()
+	if file.loaded {
+		return file.values, file.err
+	}
+
+	file.loaded = true
+	file.err = load("CONFIG", &file.config, nil, true)
+	if file.config.Path == "" {
+		return file.values, file.err
+	}
+
+	content, values, err := readFile(file.config.Path)
+	if err != nil {
+		errs := &Error{}
+		errs.add("CONFIG_FILE", file.config.Path, false, err)
+		file.err = Merge(file.err, errs)
+		logrus.WithError(err).Error("Invalid configuration file")
+		return file.values, file.err
+	}
+	file.content, file.values = content, values
+	watchDir(file.config.Path)
+	logrus.WithField("file", file.config.Path).Debug("Configuration file loaded")
+	return file.values, file.err
+}
+
+// Forgets the configuration file once the watcher stopped, so it's read again by the next configuration loaded (e.g. by tests changing CONFIG_FILE).
+func resetFile() {
+	stopWatching()
+
+	file.Lock()
+	defer file.Unlock()
+	file.loaded, file.config, file.err, file.content, file.values = false, fileConfig{}, nil, nil, nil
+}
+
+// Reads and parses a configuration file, using its extension to determine the format (e.g. yaml or json).
+func readFile(path string) ([]byte, map[string]interface{}, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	values, err := parseFile(path, content)
+	if err != nil {
+		return nil, nil, err
+	}
+	return content, values, nil
+}
+
+func parseFile(path string, content []byte) (map[string]interface{}, error) {
+	v := viper.New()
+	v.SetConfigType(strings.TrimPrefix(filepath.Ext(path), "."))
+	if err := v.ReadConfig(bytes.NewReader(content)); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return v.AllSettings(), nil
+}
+
+// Returns the raw value of a setting in the configuration file, and the key it was found at.
+// Settings are grouped by the lowercase prefix (e.g. probes.port), but can also be set using their environment variable name (e.g. probes_port).
+func fileValue(values map[string]interface{}, prefix, name string) (string, string) {
+	prefix, name = strings.ToLower(prefix), strings.ToLower(name)
+	if section, ok := values[prefix].(map[string]interface{}); ok && prefix != "" {
+		if value, ok := section[name]; ok {
+			return prefix + "." + name, raw(value)
+		}
+	}
+
+	key := name
+	if prefix != "" {
+		key = prefix + "_" + name
+	}
+	if value, ok := values[key]; ok {
+		return key, raw(value)
+	}
+	return "", ""
+}
+
+// Formats a value of the configuration file like it would be set in an environment variable.
+func raw(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case float64:
+		// JSON numbers are always floats.
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case []interface{}:
+		items := make([]string, len(value))
+		for i, item := range value {
+			items[i] = raw(item)
+		}
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// Reads the configuration file again, returning true if its settings changed.
+func reloadFile(path string) bool {
+	logEntry := logrus.WithField("file", path)
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		logEntry.WithError(err).Error("Configuration file could not be read, keeping the current configuration")
+		return false
+	}
+
+	file.Lock()
+	unchanged := bytes.Equal(content, file.content)
+	file.content = content
+	file.Unlock()
+	if unchanged {
+		return false
+	}
+
+	values, err := parseFile(path, content)
+	if err != nil {
+		logEntry.WithError(err).Error("Configuration file is invalid, keeping the current configuration")
+		return false
+	}
+
+	file.Lock()
+	file.values = values
+	file.Unlock()
+	logEntry.Info("Configuration file changed")
+	return true
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Environment variable of a setting, as listed by Loaded().
+type Variable struct {
+	Env        string       // Name of the environment variable (e.g. PROBES_PORT).
+	Prefix     string       // Prefix the configuration was loaded with (e.g. PROBES).
+	Struct     reflect.Type // Configuration struct declaring the setting.
+	Field      string       // Name of the struct field.
+	Type       reflect.Type // Type of the struct field.
+	Default    string
+	HasDefault bool
+	Required   bool
+	Secret     bool
+	Min        string
+	Max        string
+}
+
+// Variables of all configurations loaded so far.
+var loaded = struct {
+	sync.Mutex
+	variables []Variable
+	envs      map[string]bool
+}{envs: map[string]bool{}}
+
+func remember(prefix string, structType reflect.Type, settings []setting) {
+	loaded.Lock()
+	defer loaded.Unlock()
+
+	for _, s := range settings {
+		if loaded.envs[s.env] {
+			continue
+		}
+		loaded.envs[s.env] = true
+		loaded.variables = append(loaded.variables, Variable{
+			Env:        s.env,
+			Prefix:     prefix,
+			Struct:     structType,
+			Field:      s.field,
+			Type:       s.value.Type(),
+			Default:    s.def,
+			HasDefault: s.hasDefault,
+			Required:   s.required,
+			Secret:     s.secret,
+			Min:        s.min,
+			Max:        s.max,
+		})
+	}
+}
+
+// Lists the environment variables of all configurations loaded so far (see Load()), in the order they were first loaded.
+// Used to document the settings of an application (see cmd/envref). Each of them can also be read from a file (see the _FILE variables).
+func Loaded() []Variable {
+	loaded.Lock()
+	defer loaded.Unlock()
+
+	return append([]Variable(nil), loaded.variables...)
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"bytes"
+	"github.com/sirupsen/logrus"
+	"io/ioutil"
+	"strings"
+	"sync"
+)
+
+// Files settings were read from (see the _FILE variables in Load()), with their content when they were last checked for changes.
+var secrets = struct {
+	sync.Mutex
+	files map[string][]byte
+}{files: map[string][]byte{}}
+
+// Reads the value of a setting from a file, ignoring trailing newlines.
+// The file is watched for changes from then on (see Watch()).
+func readSecret(path string) (string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	secrets.Lock()
+	if _, ok := secrets.files[path]; !ok {
+		secrets.files[path] = content
+	}
+	secrets.Unlock()
+	watchDir(path)
+
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+// Reads the files settings were read from again, returning true if any of them changed (e.g. when a secret was rotated).
+// Files that can't be read are logged and ignored, as they may be in the middle of being replaced.
+func secretsChanged() bool {
+	secrets.Lock()
+	defer secrets.Unlock()
+
+	changed := false
+	for path, previous := range secrets.files {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			logrus.WithField("file", path).WithError(err).Error("Secret file could not be read, keeping the current configuration")
+			continue
+		}
+		if bytes.Equal(content, previous) {
+			continue
+		}
+		secrets.files[path] = content
+		logrus.WithField("file", path).Info("Secret file changed")
+		changed = true
+	}
+	return changed
+}
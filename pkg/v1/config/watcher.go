@@ -0,0 +1,164 @@
+package config
+
+import (
+	"context"
+	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Watcher of the directories of the configuration file and of the secret files, shared by all Watch() calls of the process.
+// Directories are watched instead of the files, so files replaced by a rename or a symlink swap (e.g. Kubernetes ConfigMap and Secret volumes) are noticed.
+var watcher = struct {
+	sync.Mutex
+	fs          *fsnotify.Watcher
+	stopped     chan struct{} // Closed once the events of the watcher are no longer handled.
+	dirs        map[string]bool
+	subscribers map[int]func()
+	next        int
+}{dirs: map[string]bool{}, subscribers: map[int]func(){}}
+
+// Watches the configuration file (see CONFIG_FILE) and the files settings were read from (see Load()) until the context is done,
+// calling onChange whenever their content changed.
+// Files are checked CONFIG_RELOAD_DELAY after their directory changed. A configuration file that can't be read or parsed is rejected and logged, and the previous settings are kept.
+// The directories are watched once per process, however many Stacks call Watch(). Returns right away if reloading is disabled.
+func Watch(ctx context.Context, onChange func()) {
+	_, _ = fileValues()
+	file.RLock()
+	path, reload := file.config.Path, file.config.Reload
+	file.RUnlock()
+	if !reload {
+		return
+	}
+
+	watcher.Lock()
+	if watcher.fs == nil {
+		fs, err := fsnotify.NewWatcher()
+		if err != nil {
+			watcher.Unlock()
+			logrus.WithError(err).Error("Configuration files can't be watched, changes won't be applied")
+			return
+		}
+		watcher.fs, watcher.stopped = fs, make(chan struct{})
+		go watchEvents(fs, watcher.stopped)
+	}
+	id := watcher.next
+	watcher.next++
+	watcher.subscribers[id] = onChange
+	watcher.Unlock()
+	defer func() {
+		watcher.Lock()
+		delete(watcher.subscribers, id)
+		watcher.Unlock()
+	}()
+
+	// Files read before the watcher started, the ones read later are added as they are read.
+	if path != "" {
+		watchDir(path)
+	}
+	secrets.Lock()
+	paths := make([]string, 0, len(secrets.files))
+	for secret := range secrets.files {
+		paths = append(paths, secret)
+	}
+	secrets.Unlock()
+	for _, secret := range paths {
+		watchDir(secret)
+	}
+
+	<-ctx.Done()
+}
+
+// Watches the directory of the file, once the watcher is started.
+func watchDir(path string) {
+	dir := filepath.Dir(path)
+
+	watcher.Lock()
+	defer watcher.Unlock()
+	if watcher.fs == nil || watcher.dirs[dir] {
+		return
+	}
+	if err := watcher.fs.Add(dir); err != nil {
+		logrus.WithField("dir", dir).WithError(err).Error("Directory could not be watched, changes to its files won't be applied")
+		return
+	}
+	watcher.dirs[dir] = true
+}
+
+// Checks the watched files once their directories changed, waiting for the reload delay so files replaced in several steps are complete.
+func watchEvents(fs *fsnotify.Watcher, stopped chan struct{}) {
+	defer close(stopped)
+
+	var check <-chan time.Time
+	for {
+		select {
+		case event, ok := <-fs.Events:
+			if !ok {
+				return
+			}
+			if event.Op&fsnotify.Remove != 0 {
+				// The watch of a removed directory is removed as well, so it's watched again if it's recreated.
+				watcher.Lock()
+				delete(watcher.dirs, event.Name)
+				watcher.Unlock()
+			}
+			if event.Op == fsnotify.Chmod || check != nil {
+				continue
+			}
+			file.RLock()
+			delay := file.config.ReloadDelay
+			file.RUnlock()
+			check = time.After(delay)
+		case err, ok := <-fs.Errors:
+			if !ok {
+				return
+			}
+			logrus.WithError(err).Error("Error while watching configuration files")
+		case <-check:
+			check = nil
+			checkFiles()
+		}
+	}
+}
+
+// Reads the configuration file and the secret files again, notifying the subscribers of Watch() if any of them changed.
+func checkFiles() {
+	file.RLock()
+	path := file.config.Path
+	file.RUnlock()
+
+	changed := path != "" && reloadFile(path)
+	if !secretsChanged() && !changed {
+		return
+	}
+
+	watcher.Lock()
+	subscribers := make([]func(), 0, len(watcher.subscribers))
+	for _, onChange := range watcher.subscribers {
+		subscribers = append(subscribers, onChange)
+	}
+	watcher.Unlock()
+	for _, onChange := range subscribers {
+		onChange()
+	}
+}
+
+// Stops the watcher, waiting for the files being checked, so the state of the configuration files can be reset (see resetFile()).
+// The next Watch() starts a new watcher.
+func stopWatching() {
+	watcher.Lock()
+	fs, stopped := watcher.fs, watcher.stopped
+	watcher.fs, watcher.stopped = nil, nil
+	watcher.dirs, watcher.subscribers = map[string]bool{}, map[int]func(){}
+	watcher.Unlock()
+	if fs == nil {
+		return
+	}
+
+	if err := fs.Close(); err != nil {
+		logrus.WithError(err).Error("Error while closing the configuration files watcher")
+	}
+	<-stopped
+}
@@ -6,46 +6,43 @@ import (
 	grpcProvider "github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/grpc"
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/metadata"
-	"google.golang.org/grpc/status"
 )
 
 type authenticationInterceptorKey struct{}
 
+// Returns the JWT of the request, authenticated by the JWTAuthenticator, or nil if it wasn't.
 func FromInterceptorContext(ctx context.Context) *jwt.JwtOperator {
-	return ctx.Value(authenticationInterceptorKey{}).(*jwt.JwtOperator)
+	operator, _ := ctx.Value(authenticationInterceptorKey{}).(*jwt.JwtOperator)
+	return operator
 }
 
+// Returns the custom options authenticating the requests of the GRPC Server with their JWT (see JWTAuthenticator).
 func CustomAuthenticationInterceptorOpts() (opt grpcProvider.CustomOpts) {
 	return grpcProvider.CustomOpts{
-		UnaryInterceptor:  []grpc.UnaryServerInterceptor{UnaryServerInterceptor()},
-		StreamInterceptor: []grpc.StreamServerInterceptor{StreamServerInterceptor()},
+		Authenticator: NewJWTAuthenticator(),
 	}
 }
+
+// Deprecated: the GRPC Server authenticates requests itself, use CustomAuthenticationInterceptorOpts() or NewJWTAuthenticator() instead.
 func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	authenticator := NewJWTAuthenticator()
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
-		operator, err := jwt.NewJwtOperator(ctx)
+		ctx, err = authenticator.Authenticate(ctx)
 		if err != nil {
-			return nil, status.Errorf(codes.Unauthenticated, err.Error())
+			return nil, err
 		}
-
-		ctx = metadata.AppendToOutgoingContext(ctx, jwt.Authorization, operator.Token())
-		ctx = context.WithValue(ctx, authenticationInterceptorKey{}, operator)
 		return handler(ctx, req)
 	}
 }
 
+// Deprecated: the GRPC Server authenticates requests itself, use CustomAuthenticationInterceptorOpts() or NewJWTAuthenticator() instead.
 func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	authenticator := NewJWTAuthenticator()
 	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-		ctx := ss.Context()
-		operator, err := jwt.NewJwtOperator(ctx)
+		newCtx, err := authenticator.Authenticate(ss.Context())
 		if err != nil {
-			return status.Errorf(codes.Unauthenticated, err.Error())
+			return err
 		}
-
-		newCtx := metadata.AppendToOutgoingContext(ctx, jwt.Authorization, operator.Token())
-		newCtx = context.WithValue(newCtx, authenticationInterceptorKey{}, operator)
 		wrappedStream := grpc_middleware.WrapServerStream(ss)
 		wrappedStream.WrappedContext = newCtx
 		return handler(srv, wrappedStream)
@@ -0,0 +1,71 @@
+package authentication
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"github.azc.ext.hp.com/hp-business-platform/lib-core-go/pkg/v1/test"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestAuthentication(t *testing.T) {
+	RegisterFailHandlerWithT(t, Fail)
+	RunSpecsWithDefaultAndCustomReporters(t, "Authentication test", test.LoadCustomReporters("../../test_middleware_authentication.xml"))
+}
+
+var _ = Describe("Authentication", func() {
+	It("Authenticates requests with an API key", func() {
+		config := &Config{APIKeyHeader: "x-api-key", APIKeys: []string{"billing:s3cr3t", "reports:t0p"}}
+		Expect(config.Validate()).To(Succeed())
+		a := NewAPIKeyAuthenticator(config)
+
+		_, err := a.Authenticate(context.Background())
+		Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+		_, err = a.Authenticate(metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-api-key", "guess")))
+		Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+
+		ctx, err := a.Authenticate(metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-api-key", "t0p")))
+		Expect(err).ToNot(HaveOccurred())
+		client, ok := APIClientFromContext(ctx)
+		Expect(ok).To(BeTrue())
+		Expect(client).To(Equal("reports"))
+	})
+	It("Rejects API keys without client name", func() {
+		Expect((&Config{APIKeys: []string{"s3cr3t"}}).Validate()).ToNot(Succeed())
+	})
+	It("Authenticates requests with a JWT", func() {
+		a := NewJWTAuthenticator()
+		_, err := a.Authenticate(context.Background())
+		Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+
+		// {"alg":"none"}.{"user_id":"29ab5fff-c81d-44f4-82b5-6d619d453f02"}.
+		token := "Bearer eyJhbGciOiJub25lIn0.eyJ1c2VyX2lkIjoiMjlhYjVmZmYtYzgxZC00NGY0LTgyYjUtNmQ2MTlkNDUzZjAyIn0."
+		ctx, err := a.Authenticate(metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", token)))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(FromInterceptorContext(ctx).UserID).To(Equal("29ab5fff-c81d-44f4-82b5-6d619d453f02"))
+	})
+	It("Authenticates requests with the verified certificate of the client", func() {
+		withPeer := func(commonName string) context.Context {
+			cert := &x509.Certificate{Subject: pkix.Name{CommonName: commonName}}
+			return peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+				VerifiedChains: [][]*x509.Certificate{{cert}},
+			}}})
+		}
+		a := NewPeerAuthenticator(&Config{AllowedPeers: []string{"billing"}})
+
+		_, err := a.Authenticate(context.Background())
+		Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+		_, err = a.Authenticate(withPeer("reports"))
+		Expect(status.Code(err)).To(Equal(codes.PermissionDenied))
+		_, err = a.Authenticate(withPeer("billing"))
+		Expect(err).ToNot(HaveOccurred())
+	})
+})
@@ -0,0 +1,116 @@
+package authentication
+
+import (
+	"context"
+	"crypto/subtle"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/middleware/jwt"
+	grpcProvider "github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/grpc"
+	"github.com/grpc-ecosystem/go-grpc-middleware/tags"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Authenticates requests with the JWT of their Authorization metadata.
+// The JWT is available to the handlers (see FromInterceptorContext()) and forwarded to the services they call.
+type JWTAuthenticator struct{}
+
+// Creates a JWT authenticator.
+func NewJWTAuthenticator() *JWTAuthenticator {
+	return &JWTAuthenticator{}
+}
+
+// Authenticates the request with its JWT.
+func (a *JWTAuthenticator) Authenticate(ctx context.Context) (context.Context, error) {
+	operator, err := jwt.NewJwtOperator(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, err.Error())
+	}
+
+	grpc_ctxtags.Extract(ctx).Set("auth.user_id", operator.UserID)
+	ctx = metadata.AppendToOutgoingContext(ctx, jwt.Authorization, operator.Token())
+	return context.WithValue(ctx, authenticationInterceptorKey{}, operator), nil
+}
+
+type apiClientKey struct{}
+
+// Authenticates requests with an API key, sent in the metadata key configured by APIKeyHeader.
+// The name of the client the key belongs to is available to the handlers (see APIClientFromContext()).
+type APIKeyAuthenticator struct {
+	header string
+	keys   map[string]string // Names of the clients, by key.
+}
+
+// Creates an API key authenticator, accepting the API keys of the configuration.
+func NewAPIKeyAuthenticator(config *Config) *APIKeyAuthenticator {
+	a := &APIKeyAuthenticator{
+		header: config.APIKeyHeader,
+		keys:   make(map[string]string, len(config.APIKeys)),
+	}
+	for _, key := range config.APIKeys {
+		if name, secret := splitAPIKey(key); name != "" && secret != "" {
+			a.keys[secret] = name
+		}
+	}
+	return a
+}
+
+// Authenticates the request with its API key. Keys are compared in constant time.
+func (a *APIKeyAuthenticator) Authenticate(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(a.header)
+	if len(values) == 0 || values[0] == "" {
+		return nil, status.Error(codes.Unauthenticated, "API key missing")
+	}
+
+	name := ""
+	for key, client := range a.keys {
+		if subtle.ConstantTimeCompare([]byte(values[0]), []byte(key)) == 1 {
+			name = client
+		}
+	}
+	if name == "" {
+		return nil, status.Error(codes.Unauthenticated, "invalid API key")
+	}
+
+	grpc_ctxtags.Extract(ctx).Set("auth.api_client", name)
+	return context.WithValue(ctx, apiClientKey{}, name), nil
+}
+
+// Returns the name of the client authenticated with an API key (see APIKeyAuthenticator).
+func APIClientFromContext(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(apiClientKey{}).(string)
+	return name, ok
+}
+
+// Authenticates requests with the certificate presented by the client (mTLS, see the GRPC_TLS_CLIENT_CA_FILE setting).
+// The identity of the client is available to the handlers (see grpc.PeerIdentityFromContext()).
+type PeerAuthenticator struct {
+	allowed map[string]bool
+}
+
+// Creates an mTLS peer authenticator, accepting the peers allowed by the configuration (or any verified peer if none is).
+func NewPeerAuthenticator(config *Config) *PeerAuthenticator {
+	a := &PeerAuthenticator{allowed: make(map[string]bool, len(config.AllowedPeers))}
+	for _, peer := range config.AllowedPeers {
+		a.allowed[peer] = true
+	}
+	return a
+}
+
+// Authenticates the request with the verified certificate of the client, matching its common name or URIs against the allowed peers.
+func (a *PeerAuthenticator) Authenticate(ctx context.Context) (context.Context, error) {
+	identity, ok := grpcProvider.PeerIdentityFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "client certificate missing")
+	}
+	if len(a.allowed) == 0 || a.allowed[identity.CommonName] {
+		return ctx, nil
+	}
+	for _, uri := range identity.URIs {
+		if a.allowed[uri] {
+			return ctx, nil
+		}
+	}
+	return nil, status.Errorf(codes.PermissionDenied, "client %s isn't allowed", identity.CommonName)
+}
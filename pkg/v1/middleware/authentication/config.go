@@ -0,0 +1,45 @@
+package authentication
+
+import (
+	"fmt"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
+	"github.com/sirupsen/logrus"
+	"strings"
+)
+
+// Configuration for the authenticators of the GRPC Server.
+type Config struct {
+	config.Result
+
+	APIKeyHeader string   `env:"API_KEY_HEADER" default:"x-api-key"` // Metadata key (header) in which clients send their API key.
+	APIKeys      []string `env:"API_KEYS" secret:"true"`             // Comma separated API keys accepted by the API key authenticator, each prefixed by the name of its client (e.g. billing:s3cr3t).
+	AllowedPeers []string `env:"ALLOWED_PEERS"`                      // Comma separated common names or URIs (e.g. SPIFFE IDs) of the client certificates accepted by the mTLS peer authenticator. Empty accepts any verified certificate.
+}
+
+// Initializes the configuration from environment variables.
+func NewConfigFromEnv() *Config {
+	c := &Config{}
+	_ = config.Load("AUTHN", c)
+
+	logrus.WithFields(config.Fields(c)).Debug("Authentication Config initialized")
+
+	return c
+}
+
+// Checks the API keys are prefixed by the name of their client.
+func (c *Config) Validate() error {
+	for i, key := range c.APIKeys {
+		if name, secret := splitAPIKey(key); name == "" || secret == "" {
+			return fmt.Errorf("API key %d should be formatted as client:key", i+1)
+		}
+	}
+	return nil
+}
+
+func splitAPIKey(key string) (string, string) {
+	parts := strings.SplitN(key, ":", 2)
+	if len(parts) != 2 {
+		return "", ""
+	}
+	return parts[0], parts[1]
+}
@@ -8,7 +8,6 @@ import (
 	"github.azc.ext.hp.com/hp-business-platform/lib-hpbp-rest-go/gen/authz_service/client/authorization"
 	"github.azc.ext.hp.com/hp-business-platform/lib-hpbp-rest-go/gen/authz_service/models"
 	"github.com/go-openapi/strfmt"
-	"github.com/spf13/viper"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -32,17 +31,9 @@ type Interceptor struct {
 	skipper     Skipper
 }
 
-const defaultAuthzServiceHost = "hpbp.hpbp.io"
-
 // NewInterceptor .
 func NewInterceptor(confFuncs ...ConfigFunc) *Interceptor {
-	v := viper.New()
-	v.SetEnvPrefix("AUTHZ")
-	v.AutomaticEnv()
-	v.SetDefault("SERVICE_HOST", defaultAuthzServiceHost)
-	c := &Config{
-		AuthzServiceHost: v.GetString("SERVICE_HOST"),
-	}
+	c := NewConfigFromEnv()
 	for _, confFun := range confFuncs {
 		confFun(c)
 	}
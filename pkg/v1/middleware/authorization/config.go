@@ -1,16 +1,32 @@
 package authorization
 
-import "github.azc.ext.hp.com/hp-business-platform/lib-hpbp-rest-go/gen/authz_service/client/authorization"
+import (
+	"github.azc.ext.hp.com/hp-business-platform/lib-hpbp-rest-go/gen/authz_service/client/authorization"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
+	"github.com/sirupsen/logrus"
+)
 
 // Config .
 type Config struct {
-	AuthzServiceHost   string
+	config.Result
+
+	AuthzServiceHost   string `env:"SERVICE_HOST" default:"hpbp.hpbp.io"` // Host of the authz service, used unless an AuthzClient is set.
 	AuthzClient        authorization.ClientService
 	UserGetter         IUserGetter
 	OrganizationGetter IOrganizationGetter
 	Skipper            Skipper
 }
 
+// NewConfigFromEnv initializes the configuration from environment variables.
+func NewConfigFromEnv() *Config {
+	c := &Config{}
+	_ = config.Load("AUTHZ", c)
+
+	logrus.WithFields(config.Fields(c)).Debug("Authz Config initialized")
+
+	return c
+}
+
 // Skipper the skipper func type
 type Skipper func(string) bool
 
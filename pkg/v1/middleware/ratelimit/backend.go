@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Token bucket limit: the bucket holds up to Burst tokens and is refilled at Rate tokens per second, each request takes a token.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Stores the token buckets. The MemoryBackend limits each replica separately,
+// a shared backend (e.g. Redis) allows limiting the requests of all replicas together.
+type Backend interface {
+	// Takes a token from the bucket of the key. If none is left, returns false and how long to wait until a token is available.
+	Take(ctx context.Context, key string, limit Limit) (allowed bool, retryAfter time.Duration, err error)
+}
+
+// How often the MemoryBackend forgets the buckets that were refilled completely, so keys don't accumulate.
+const sweepInterval = time.Minute
+
+// Backend keeping the token buckets in memory, for a single replica.
+type MemoryBackend struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+// Returns the number of tokens in the bucket by now.
+func (bk *bucket) refill(now time.Time) float64 {
+	return math.Min(float64(bk.limit.Burst), bk.tokens+now.Sub(bk.updated).Seconds()*bk.limit.Rate)
+}
+
+// Creates a MemoryBackend.
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{
+		buckets:   map[string]*bucket{},
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Takes a token from the bucket of the key, creating a full bucket if the key is new.
+func (b *MemoryBackend) Take(_ context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if now.Sub(b.lastSweep) >= sweepInterval {
+		b.sweep(now)
+	}
+
+	bk, ok := b.buckets[key]
+	if !ok {
+		bk = &bucket{tokens: float64(limit.Burst), updated: now, limit: limit}
+		b.buckets[key] = bk
+	}
+	bk.tokens = bk.refill(now)
+	bk.updated = now
+	bk.limit = limit
+
+	if bk.tokens >= 1 {
+		bk.tokens--
+		return true, 0, nil
+	}
+	if limit.Rate <= 0 {
+		return false, sweepInterval, nil
+	}
+	return false, time.Duration((1 - bk.tokens) / limit.Rate * float64(time.Second)), nil
+}
+
+// Removes the buckets that are full by now, as they would be created again in the same state.
+func (b *MemoryBackend) sweep(now time.Time) {
+	b.lastSweep = now
+	for key, bk := range b.buckets {
+		if bk.refill(now) >= float64(bk.limit.Burst) {
+			delete(b.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"fmt"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
+	"github.com/sirupsen/logrus"
+	"strconv"
+	"strings"
+)
+
+// Request properties the limits can be keyed by.
+const (
+	KeyMethod = "method" // The full method name (e.g. /api.PingService/Ping).
+	KeyTenant = "tenant" // The tenant ID set by the tenant interceptor.
+	KeyUser   = "user"   // The user ID of the JWT authenticated by the authentication middleware.
+)
+
+// Configuration for the rate limiting interceptors of the GRPC Server.
+type Config struct {
+	config.Result
+
+	Rate         float64  `env:"RATE" default:"100" min:"0"`                                                                // Number of requests per second allowed for each key. Zero allows no more requests once the burst is used.
+	Burst        int      `env:"BURST" default:"200" min:"1"`                                                               // Number of requests allowed at once for each key, before the rate applies.
+	KeyBy        []string `env:"KEY_BY" default:"method,tenant"`                                                            // Comma separated request properties the limits are keyed by: method, tenant and/or user. Empty shares one limit between all requests.
+	MethodLimits []string `env:"METHOD_LIMITS"`                                                                             // Comma separated limits of specific methods or services, overriding the rate and burst (e.g. /api.PingService/Ping=10:20 or /api.PingService/=50).
+	SkipMethods  []string `env:"SKIP_METHODS" default:"/grpc.health.v1.Health/,/grpc.reflection.v1alpha.ServerReflection/"` // Comma separated methods (e.g. /api.PingService/Ping) or services (e.g. /grpc.health.v1.Health/) that aren't limited.
+}
+
+// Initializes the configuration from environment variables.
+func NewConfigFromEnv() *Config {
+	c := &Config{}
+	_ = config.Load("GRPC_RATELIMIT", c)
+
+	logrus.WithFields(config.Fields(c)).Debug("Rate limiting Config initialized")
+
+	return c
+}
+
+// Checks the keys and the method limits.
+func (c *Config) Validate() error {
+	for _, key := range c.KeyBy {
+		if key != KeyMethod && key != KeyTenant && key != KeyUser {
+			return fmt.Errorf("unsupported rate limiting key %s, should be %s, %s or %s", key, KeyMethod, KeyTenant, KeyUser)
+		}
+	}
+	_, err := c.methodLimits()
+	return err
+}
+
+// Parses the method limits, formatted as method=rate or method=rate:burst.
+// The burst defaults to the configured burst, or to the rate if that's larger.
+func (c *Config) methodLimits() (map[string]Limit, error) {
+	limits := map[string]Limit{}
+	for _, methodLimit := range c.MethodLimits {
+		parts := strings.SplitN(methodLimit, "=", 2)
+		if len(parts) != 2 || !strings.HasPrefix(parts[0], "/") {
+			return nil, fmt.Errorf("method limit %s should be formatted as /service/method=rate:burst", methodLimit)
+		}
+		values := strings.SplitN(parts[1], ":", 2)
+		rate, err := strconv.ParseFloat(values[0], 64)
+		if err != nil || rate < 0 {
+			return nil, fmt.Errorf("invalid rate in method limit %s", methodLimit)
+		}
+		limit := Limit{Rate: rate, Burst: c.Burst}
+		if len(values) == 2 {
+			if limit.Burst, err = strconv.Atoi(values[1]); err != nil || limit.Burst < 1 {
+				return nil, fmt.Errorf("invalid burst in method limit %s", methodLimit)
+			}
+		} else if float64(limit.Burst) < rate {
+			limit.Burst = int(rate)
+		}
+		limits[parts[0]] = limit
+	}
+	return limits, nil
+}
@@ -0,0 +1,173 @@
+package ratelimit
+
+import (
+	"context"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/middleware/authentication"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/middleware/tenant"
+	grpcProvider "github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/grpc"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Metadata key (header) telling rejected clients how many seconds to wait before retrying.
+const RetryAfterHeader = "retry-after"
+
+// Prometheus counters, exposed by the Prometheus Provider.
+var (
+	rejectedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_server_ratelimit_rejected_total",
+		Help: "Number of requests rejected by the rate limiting interceptors of the GRPC Server.",
+	}, []string{"grpc_service", "grpc_method"})
+	backendErrorsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_server_ratelimit_backend_errors_total",
+		Help: "Number of requests allowed by the rate limiting interceptors of the GRPC Server because the backend failed.",
+	}, []string{"grpc_service", "grpc_method"})
+)
+
+func init() {
+	prometheus.MustRegister(rejectedCounter, backendErrorsCounter)
+}
+
+// Limits the rate of the requests of the GRPC Server with token buckets, keyed by method, tenant and/or user (see Config).
+// Rejected requests fail with the ResourceExhausted code, with the delay before retrying in their RetryInfo details and retry-after header.
+// Requests are allowed if the backend fails, so an unavailable shared backend doesn't take the service down.
+type Limiter struct {
+	config       *Config
+	backend      Backend
+	methodLimits map[string]Limit
+}
+
+// Creates a Limiter, storing its buckets in the backend, or in memory if nil (limiting each replica separately).
+func New(config *Config, backend Backend) (*Limiter, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	methodLimits, _ := config.methodLimits()
+	if backend == nil {
+		backend = NewMemoryBackend()
+	}
+	return &Limiter{config: config, backend: backend, methodLimits: methodLimits}, nil
+}
+
+// Returns the custom options limiting the rate of the requests of the GRPC Server.
+// Should come after the custom options of the tenant interceptors when limiting by tenant, so the tenant is known.
+func CustomRateLimitInterceptorOpts(config *Config, backend Backend) (grpcProvider.CustomOpts, error) {
+	l, err := New(config, backend)
+	if err != nil {
+		return grpcProvider.CustomOpts{}, err
+	}
+	return grpcProvider.CustomOpts{
+		UnaryInterceptor:  []grpc.UnaryServerInterceptor{l.UnaryServerInterceptor()},
+		StreamInterceptor: []grpc.StreamServerInterceptor{l.StreamServerInterceptor()},
+	}, nil
+}
+
+// Limits the rate of unary requests.
+func (l *Limiter) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := l.take(ctx, info.FullMethod, func(md metadata.MD) error { return grpc.SetHeader(ctx, md) }); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Limits the rate of streams, once when they start.
+func (l *Limiter) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := l.take(ss.Context(), info.FullMethod, ss.SetHeader); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// Takes a token for the request, returning the error to reject it with if none is left.
+func (l *Limiter) take(ctx context.Context, fullMethodName string, setHeader func(metadata.MD) error) error {
+	if _, skipped := match(l.config.SkipMethods, fullMethodName); skipped {
+		return nil
+	}
+
+	limit := Limit{Rate: l.config.Rate, Burst: l.config.Burst}
+	scope := ""
+	if pattern, ok := matchLimit(l.methodLimits, fullMethodName); ok {
+		limit, scope = l.methodLimits[pattern], pattern
+	}
+
+	service, method := path.Dir(fullMethodName)[1:], path.Base(fullMethodName)
+	allowed, retryAfter, err := l.backend.Take(ctx, l.key(ctx, scope, fullMethodName), limit)
+	if err != nil {
+		logrus.WithError(err).WithField("grpc.method", fullMethodName).Warn("Rate limiting backend failed, allowing the request")
+		backendErrorsCounter.WithLabelValues(service, method).Inc()
+		return nil
+	}
+	if allowed {
+		return nil
+	}
+
+	rejectedCounter.WithLabelValues(service, method).Inc()
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	_ = setHeader(metadata.Pairs(RetryAfterHeader, strconv.Itoa(seconds)))
+	st := status.New(codes.ResourceExhausted, "rate limit exceeded, retry later")
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: ptypes.DurationProto(time.Duration(seconds) * time.Second)}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// Builds the key of the bucket from the scope of the method limit (if any) and the configured request properties.
+// Requests without tenant or authenticated user share the bucket of the empty tenant or user.
+func (l *Limiter) key(ctx context.Context, scope, fullMethodName string) string {
+	parts := []string{scope}
+	for _, key := range l.config.KeyBy {
+		value := ""
+		switch key {
+		case KeyMethod:
+			value = fullMethodName
+		case KeyTenant:
+			value, _ = tenant.FromTenantInterceptorContext(ctx)
+		case KeyUser:
+			if operator := authentication.FromInterceptorContext(ctx); operator != nil {
+				value = operator.UserID
+			}
+		}
+		parts = append(parts, key+"="+value)
+	}
+	return strings.Join(parts, "|")
+}
+
+// Returns the pattern matching the method, either by its full name or by the name of its service (e.g. "/api.PingService/").
+func match(patterns []string, fullMethodName string) (string, bool) {
+	for _, pattern := range patterns {
+		if pattern == fullMethodName || (strings.HasSuffix(pattern, "/") && strings.HasPrefix(fullMethodName, pattern)) {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// Returns the method limit of the method, preferring the limit of the method over the one of its service.
+func matchLimit(limits map[string]Limit, fullMethodName string) (string, bool) {
+	if _, ok := limits[fullMethodName]; ok {
+		return fullMethodName, true
+	}
+	service := path.Dir(fullMethodName) + "/"
+	if _, ok := limits[service]; ok {
+		return service, true
+	}
+	return "", false
+}
@@ -0,0 +1,144 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.azc.ext.hp.com/hp-business-platform/lib-core-go/pkg/v1/test"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/examples/ping/server/gen"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/middleware/tenant"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider"
+	grpcProvider "github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/grpc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	RegisterFailHandlerWithT(t, Fail)
+	RunSpecsWithDefaultAndCustomReporters(t, "Rate limiting test", test.LoadCustomReporters("../../test_middleware_ratelimit.xml"))
+}
+
+type failingBackend struct{}
+
+func (failingBackend) Take(context.Context, string, Limit) (bool, time.Duration, error) {
+	return false, 0, errors.New("backend unavailable")
+}
+
+type pingService struct{}
+
+func (pingService) Ping(ctx context.Context, request *gen.PingRequest) (*gen.PingResponse, error) {
+	return &gen.PingResponse{Out: request.In}, nil
+}
+
+var _ = Describe("Rate limiting", func() {
+	It("Refills the token buckets at the configured rate", func() {
+		now := time.Now()
+		b := NewMemoryBackend()
+		b.now = func() time.Time { return now }
+		limit := Limit{Rate: 2, Burst: 2}
+
+		for i := 0; i < 2; i++ {
+			allowed, _, err := b.Take(context.Background(), "key", limit)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(allowed).To(BeTrue())
+		}
+		allowed, retryAfter, err := b.Take(context.Background(), "key", limit)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(allowed).To(BeFalse())
+		Expect(retryAfter).To(Equal(500 * time.Millisecond))
+		allowed, _, _ = b.Take(context.Background(), "other", limit)
+		Expect(allowed).To(BeTrue())
+
+		now = now.Add(500 * time.Millisecond)
+		allowed, _, _ = b.Take(context.Background(), "key", limit)
+		Expect(allowed).To(BeTrue())
+
+		now = now.Add(sweepInterval)
+		_, _, _ = b.Take(context.Background(), "new", limit)
+		Expect(b.buckets).To(HaveLen(1))
+	})
+	It("Validates the configuration", func() {
+		Expect((&Config{Burst: 1, KeyBy: []string{"method", "client"}}).Validate()).ToNot(Succeed())
+		Expect((&Config{Burst: 1, MethodLimits: []string{"/api.PingService/Ping"}}).Validate()).ToNot(Succeed())
+		Expect((&Config{Burst: 1, MethodLimits: []string{"/api.PingService/Ping=ten"}}).Validate()).ToNot(Succeed())
+
+		limits, err := (&Config{Burst: 5, MethodLimits: []string{"/api.PingService/Ping=1:2", "/api.PingService/=10"}}).methodLimits()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(limits).To(Equal(map[string]Limit{
+			"/api.PingService/Ping": {Rate: 1, Burst: 2},
+			"/api.PingService/":     {Rate: 10, Burst: 10},
+		}))
+	})
+	It("Limits the requests of each tenant", func() {
+		l, err := New(&Config{Rate: 0, Burst: 1, KeyBy: []string{KeyMethod, KeyTenant}, SkipMethods: []string{"/grpc.health.v1.Health/"}}, nil)
+		Expect(err).ToNot(HaveOccurred())
+		call := func(tenantID, method string) error {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(tenant.XHpbpTenantID, tenantID))
+			info := &grpc.UnaryServerInfo{FullMethod: method}
+			_, err := tenant.UnaryServerInterceptor()(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return l.UnaryServerInterceptor()(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+					return nil, nil
+				})
+			})
+			return err
+		}
+		rejected := testutil.ToFloat64(rejectedCounter.WithLabelValues("api.PingService", "Ping"))
+
+		Expect(call("acme", "/api.PingService/Ping")).To(Succeed())
+		err = call("acme", "/api.PingService/Ping")
+		Expect(status.Code(err)).To(Equal(codes.ResourceExhausted))
+		Expect(status.Convert(err).Details()).To(ContainElement(BeAssignableToTypeOf(&errdetails.RetryInfo{})))
+		Expect(testutil.ToFloat64(rejectedCounter.WithLabelValues("api.PingService", "Ping"))).To(Equal(rejected + 1))
+
+		Expect(call("globex", "/api.PingService/Ping")).To(Succeed())
+		Expect(call("acme", "/api.PingService/Echo")).To(Succeed())
+		Expect(call("acme", "/grpc.health.v1.Health/Check")).To(Succeed())
+		Expect(call("acme", "/grpc.health.v1.Health/Check")).To(Succeed())
+	})
+	It("Allows the requests if the backend fails", func() {
+		l, err := New(&Config{Burst: 1}, failingBackend{})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = l.UnaryServerInterceptor()(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/api.PingService/Ping"},
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				return nil, nil
+			})
+		Expect(err).ToNot(HaveOccurred())
+	})
+	It("Tells the clients of the GRPC Server when to retry", func() {
+		opts, err := CustomRateLimitInterceptorOpts(&Config{Rate: 0.5, Burst: 1, MethodLimits: []string{"/api.PingService/Ping=0.25:1"}}, nil)
+		Expect(err).ToNot(HaveOccurred())
+		p := grpcProvider.New(&grpcProvider.Config{Port: 0}, opts)
+		Expect(p.Init()).To(Succeed())
+		gen.RegisterPingServiceServer(p.Server, pingService{})
+		go func() {
+			_ = p.Run()
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		Expect(provider.WaitReady(ctx, p)).To(Succeed())
+		defer func() {
+			Expect(p.Close()).To(Succeed())
+		}()
+
+		conn, err := grpc.Dial(fmt.Sprintf("127.0.0.1:%d", p.Addr().(*net.TCPAddr).Port), grpc.WithInsecure())
+		Expect(err).NotTo(HaveOccurred())
+		defer conn.Close()
+		client := gen.NewPingServiceClient(conn)
+
+		_, err = client.Ping(context.Background(), &gen.PingRequest{In: "Hello"})
+		Expect(err).ToNot(HaveOccurred())
+		var header metadata.MD
+		_, err = client.Ping(context.Background(), &gen.PingRequest{In: "Hello"}, grpc.Header(&header))
+		Expect(status.Code(err)).To(Equal(codes.ResourceExhausted))
+		Expect(header.Get(RetryAfterHeader)).To(Equal([]string{"4"}))
+	})
+})
@@ -0,0 +1,91 @@
+package admin
+
+import (
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/httpserver"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+// Provider that can be mounted on the Admin Provider, like the Probes, Prometheus, PProf and Status Providers.
+// Providers embedding the httpserver.Server only need to implement Mount().
+type Mounter interface {
+	provider.RunProvider
+
+	Mount(mux *http.ServeMux) // Registers the handlers of the Provider on the mux.
+	SetMounted(mounted bool)  // Marks the Provider as mounted, so it doesn't listen on a port of its own.
+}
+
+// Admin Provider.
+// Serves the handlers of operational Providers (probes, metrics, profiling, status...) on a single shared port.
+// This means less container ports, network policies and Service definitions per application.
+// If disabled, the mounted Providers each run on their own port, as configured.
+type Admin struct {
+	httpserver.Server
+
+	Config *Config
+
+	mounts   []Mounter
+	handlers []handler
+}
+
+type handler struct {
+	pattern string
+	handler http.Handler
+}
+
+// Creates an Admin Provider, mounting the given Providers if enabled.
+// Providers that should keep running on their own port shouldn't be passed.
+func New(config *Config, mounts ...Mounter) *Admin {
+	if config.Enabled {
+		for _, mount := range mounts {
+			mount.SetMounted(true)
+		}
+	}
+	return &Admin{
+		Config: config,
+		mounts: mounts,
+	}
+}
+
+// Returns the invalid settings of the Admin configuration, if any (see provider.Configured).
+func (p *Admin) ConfigError() error {
+	return config.Err(p.Config)
+}
+
+// The Admin Provider depends on the Providers it mounts, so they're initialized before (and closed after) it.
+func (p *Admin) Dependencies() []provider.Provider {
+	deps := make([]provider.Provider, len(p.mounts))
+	for i, mount := range p.mounts {
+		deps[i] = mount
+	}
+	return deps
+}
+
+// Adds an extra handler to the Admin server. Should be called before the Admin Provider runs.
+func (p *Admin) Handle(pattern string, h http.Handler) {
+	p.handlers = append(p.handlers, handler{pattern: pattern, handler: h})
+}
+
+// Creates an HTTP service on the configured port, serving the handlers of all mounted Providers.
+func (p *Admin) Run() error {
+	if !p.Config.Enabled {
+		logrus.Info("Admin Provider not enabled")
+		return nil
+	}
+
+	logEntry := logrus.WithFields(logrus.Fields{
+		"port":   p.Config.Port,
+		"mounts": len(p.mounts),
+	})
+
+	mux := http.NewServeMux()
+	for _, mount := range p.mounts {
+		mount.Mount(mux)
+	}
+	for _, h := range p.handlers {
+		mux.Handle(h.pattern, h.handler)
+	}
+	return p.Serve("Admin Provider", p.Config.Port, p.Config.Server, mux, logEntry)
+}
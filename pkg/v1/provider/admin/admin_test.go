@@ -0,0 +1,90 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"github.azc.ext.hp.com/hp-business-platform/lib-core-go/pkg/v1/test"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/app"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/pprof"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/probes"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/prometheus"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/status"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/stack"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	"net"
+	"net/http"
+	"testing"
+)
+
+// Port of the mounted Providers, which is never bound.
+const mountedPort = 8010
+
+func TestAdmin(t *testing.T) {
+	RegisterFailHandlerWithT(t, Fail)
+	RunSpecsWithDefaultAndCustomReporters(t, "Admin provider test", test.LoadCustomReporters("../../test_provider_admin.xml"))
+}
+
+var _ = Describe("Admin provider", func() {
+	It("Serves the mounted providers on a single port", func() {
+		logrus.SetLevel(logrus.DebugLevel)
+		st := stack.New()
+		appProvider := app.New(&app.Config{Name: "admin", BasePath: "/"})
+		probesProvider := probes.New(&probes.Config{Enabled: true, Port: mountedPort, LivenessEndpoint: "/healthz", ReadinessEndpoint: "/ready"}, appProvider)
+		pprofProvider := pprof.New(&pprof.Config{Enabled: true, Port: mountedPort, Endpoint: "/debug/pprof"})
+		statusProvider := status.New(&status.Config{Enabled: true, Port: mountedPort, Endpoint: "/status"}, st)
+		// Not mounted, so it keeps running on its own port.
+		prometheusProvider := prometheus.New(&prometheus.Config{Enabled: true, Port: 0, Endpoint: "/metrics"})
+
+		var p *Admin
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		errs := make(chan error, 1)
+		By("Creating the provider", func() {
+			p = New(&Config{Enabled: true, Port: 0}, probesProvider, pprofProvider, statusProvider)
+			p.Handle("/custom", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+				res.WriteHeader(http.StatusAccepted)
+			}))
+			st.Add(p, prometheusProvider)
+		})
+		By("Running the stack", func() {
+			go func() {
+				errs <- st.Run(ctx)
+			}()
+			Eventually(p.IsRunning).Should(BeTrue())
+			Eventually(prometheusProvider.IsRunning).Should(BeTrue())
+			Expect(probesProvider.IsRunning()).To(BeTrue())
+		})
+		By("Calling the mounted endpoints on the admin port", func() {
+			for path, code := range map[string]int{"/healthz": 200, "/ready": 200, "/debug/pprof/": 200, "/status": 200, "/custom": 202} {
+				res, err := http.Get(fmt.Sprintf("http://localhost:%d%s", p.Addr().(*net.TCPAddr).Port, path))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(res.StatusCode).To(Equal(code), path)
+			}
+		})
+		By("Checking the mounted providers don't listen on their own port", func() {
+			Expect(probesProvider.Addr()).To(BeNil())
+			_, err := net.Dial("tcp", fmt.Sprintf("localhost:%d", mountedPort))
+			Expect(err).To(HaveOccurred())
+		})
+		By("Calling the provider running on its own port", func() {
+			res, err := http.Get(fmt.Sprintf("http://localhost:%d/metrics", prometheusProvider.Addr().(*net.TCPAddr).Port))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.StatusCode).To(Equal(200))
+		})
+		By("Stopping the stack", func() {
+			cancel()
+			Eventually(errs, 5).Should(Receive(BeNil()))
+			Expect(probesProvider.IsRunning()).To(BeFalse())
+			Expect(p.IsRunning()).To(BeFalse())
+		})
+	})
+	It("Lets the providers run on their own port if disabled", func() {
+		pprofProvider := pprof.New(&pprof.Config{Enabled: true, Port: mountedPort, Endpoint: "/debug/pprof"})
+		p := New(&Config{Enabled: false, Port: 0}, pprofProvider)
+		Expect(pprofProvider.IsMounted()).To(BeFalse())
+		Expect(p.Run()).To(Succeed())
+		Expect(p.IsRunning()).To(BeFalse())
+	})
+})
@@ -0,0 +1,27 @@
+package admin
+
+import (
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/httpserver"
+	"github.com/sirupsen/logrus"
+)
+
+// Configuration for the Admin Provider.
+type Config struct {
+	config.Result
+
+	Enabled bool               `env:"ENABLED" default:"false"`                 // Whether or not the mounted Providers are served by the Admin Provider. If disabled, they run on their own ports.
+	Port    int                `env:"PORT" default:"8000" min:"0" max:"65535"` // Port on which to start the HTTP service.
+	Server  *httpserver.Config // Settings of the HTTP server (timeouts, TLS...). Defaults are used if nil.
+}
+
+// Initializes the configuration from environment variables.
+func NewConfigFromEnv() *Config {
+	c := &Config{}
+	_ = config.Load("ADMIN", c)
+	c.Server = httpserver.NewConfigFromEnv("ADMIN")
+
+	logrus.WithFields(config.Fields(c)).Debug("Admin Config initialized")
+
+	return c
+}
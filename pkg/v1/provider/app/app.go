@@ -2,6 +2,7 @@ package app
 
 import (
 	"github.azc.ext.hp.com/hp-business-platform/lib-core-go/pkg/v1/version"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
 	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider"
 	"github.com/sirupsen/logrus"
 	"path"
@@ -16,18 +17,31 @@ type App struct {
 }
 
 // Creates an App Provider.
+// Generates the instance ID and looks up the hostname if they weren't configured.
 func New(config *Config) *App {
+	config.resolveIdentity()
 	return &App{
 		Config: config,
 	}
 }
 
+// Returns the invalid settings of the App configuration, if any (see provider.Configured).
+func (p *App) ConfigError() error {
+	return config.Err(p.Config)
+}
+
 // App Provider doesn't need initialization, since version is set during compilation and name via environment variables.
+// Publishes the build metadata as the app_build_info Prometheus gauge (see BuildInfo()),
+// and adds the identity of the instance to every entry of the logrus standard logger, unless disabled (see Identity()).
 func (p *App) Init() error {
+	p.publishBuildInfo()
+	if p.Config.LogIdentity {
+		p.logIdentity()
+	}
 	logrus.WithFields(logrus.Fields{
 		"name":    p.Name(),
 		"version": p.Version().String(),
-	}).Info("App Provider initialized")
+	}).WithFields(p.Identity().Fields()).Info("App Provider initialized")
 	return nil
 }
 
@@ -1,11 +1,18 @@
 package app
 
 import (
+	"encoding/json"
 	"github.azc.ext.hp.com/hp-business-platform/lib-core-go/pkg/v1/test"
 	"github.azc.ext.hp.com/hp-business-platform/lib-core-go/pkg/v1/version"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"runtime"
 	"testing"
 )
 
@@ -38,6 +45,65 @@ var _ = Describe("App provider", func() {
 		Expect(p.ParsePath("sub", "elem")).To(Equal("/sub/elem/"))
 	})
 
+	It("Publishes the build metadata", func() {
+		p := New(&Config{Name: name, BasePath: "/"})
+		Expect(p.Init()).To(Succeed())
+
+		info := p.BuildInfo()
+		Expect(info.Name).To(Equal(name))
+		Expect(info.Version).To(Equal("0.13.90"))
+		Expect(info.Commit).To(Equal("a722bdb"))
+		Expect(info.BuildTime).To(Equal("2018-01-09T22:32:37+01:00"))
+		Expect(info.GoVersion).To(Equal(runtime.Version()))
+
+		By("Exposing them as the app_build_info gauge", func() {
+			Expect(testutil.ToFloat64(buildInfoGauge.WithLabelValues(name, "0.13.90", "a722bdb", "2018-01-09T22:32:37+01:00", runtime.Version()))).To(Equal(1.0))
+		})
+		By("Exposing them on the version handler", func() {
+			res := httptest.NewRecorder()
+			p.VersionHandler().ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/version", nil))
+			Expect(res.Code).To(Equal(http.StatusOK))
+
+			var published BuildInfo
+			Expect(json.NewDecoder(res.Body).Decode(&published)).To(Succeed())
+			Expect(published).To(Equal(info))
+		})
+	})
+
+	It("Resolves the identity of the instance", func() {
+		_ = os.Setenv("APP_POD_NAME", "test-5d8f9-x2x7q")
+		_ = os.Setenv("APP_NAMESPACE", "prod")
+		_ = os.Setenv("APP_REGION", "eu-west-1")
+		defer func() {
+			_ = os.Unsetenv("APP_POD_NAME")
+			_ = os.Unsetenv("APP_NAMESPACE")
+			_ = os.Unsetenv("APP_REGION")
+		}()
+		p := New(NewConfigFromEnv())
+
+		identity := p.Identity()
+		Expect(identity.Name).To(Equal(name))
+		Expect(identity.InstanceID).To(HaveLen(16))
+		Expect(identity.Hostname).ToNot(BeEmpty())
+		Expect(identity.String()).To(Equal(name + "/test-5d8f9-x2x7q"))
+		Expect(p.UserAgent()).To(Equal(name + "/0.13.90 (test-5d8f9-x2x7q; namespace=prod; region=eu-west-1)"))
+		Expect(identity.Tags()).To(HaveKeyWithValue("k8s.pod.name", "test-5d8f9-x2x7q"))
+		Expect(identity.Tags()).To(HaveKeyWithValue("service.instance.id", identity.InstanceID))
+		Expect(New(&Config{Name: "other"}).Identity().InstanceID).ToNot(Equal(identity.InstanceID), "Expected every instance to get its own ID")
+
+		By("Adding it to every log entry", func() {
+			Expect(p.Init()).To(Succeed())
+			hook := logrustest.NewGlobal()
+			defer logrus.StandardLogger().ReplaceHooks(logrus.LevelHooks{})
+
+			logrus.WithField("pod", "overridden").Info("Hello")
+			Expect(hook.LastEntry().Data).To(HaveKeyWithValue("app", name))
+			Expect(hook.LastEntry().Data).To(HaveKeyWithValue("namespace", "prod"))
+			Expect(hook.LastEntry().Data).To(HaveKeyWithValue("instance_id", identity.InstanceID))
+			Expect(hook.LastEntry().Data).To(HaveKeyWithValue("pod", "overridden"))
+		})
+	})
+
 	Context("Configuring the base path", func() {
 		It("Handles a path with suffixed and prefixed slash", func() {
 			_ = os.Setenv("APP_BASE_PATH", "/some/path/")
@@ -0,0 +1,93 @@
+package app
+
+import (
+	"encoding/json"
+	"github.azc.ext.hp.com/hp-business-platform/lib-core-go/pkg/v1/version"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// Build metadata of the application, telling exactly what is deployed.
+type BuildInfo struct {
+	Name         string            `json:"name"`
+	Version      string            `json:"version"`
+	Commit       string            `json:"commit,omitempty"`     // Git commit the application was built from.
+	BuildTime    string            `json:"build_time,omitempty"` // Time the application was built at.
+	GoVersion    string            `json:"go_version"`
+	Module       string            `json:"module,omitempty"`       // Path of the main module.
+	Dependencies map[string]string `json:"dependencies,omitempty"` // Versions of the module dependencies, by path.
+}
+
+var (
+	buildInfoGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "app_build_info",
+		Help: "Build metadata of the application, always 1.",
+	}, []string{"name", "version", "commit", "build_time", "go_version"})
+	registerBuildInfoGauge sync.Once
+)
+
+// Returns the build metadata of the application.
+// The version, commit and build time come from the build string set during compilation (see the version package of lib-core),
+// the module versions are read from the binary (see debug.ReadBuildInfo()).
+func (p *App) BuildInfo() BuildInfo {
+	info := BuildInfo{
+		Name:      p.Name(),
+		GoVersion: runtime.Version(),
+	}
+
+	// The build string starts with the version, commit and build time (e.g. "0.13.90 a722bdb 2018-01-09T22:32:37+01:00 go version go1.11 linux/amd64").
+	fields := strings.Fields(version.BuildString)
+	if len(fields) > 0 {
+		info.Version = fields[0]
+	}
+	if len(fields) > 1 {
+		info.Commit = fields[1]
+	}
+	if len(fields) > 2 {
+		info.BuildTime = fields[2]
+	}
+
+	if build, ok := debug.ReadBuildInfo(); ok {
+		info.Module = build.Main.Path
+		if info.Version == "" {
+			info.Version = build.Main.Version
+		}
+		info.Dependencies = make(map[string]string, len(build.Deps))
+		for _, dep := range build.Deps {
+			if dep.Replace != nil {
+				info.Dependencies[dep.Path] = strings.TrimSpace(dep.Replace.Path + " " + dep.Replace.Version)
+				continue
+			}
+			info.Dependencies[dep.Path] = dep.Version
+		}
+	}
+	return info
+}
+
+// Returns a handler publishing the build metadata as JSON (see BuildInfo()).
+func (p *App) VersionHandler() http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(res).Encode(p.BuildInfo()); err != nil {
+			logrus.WithError(err).Error("Build info could not be written")
+		}
+	})
+}
+
+// Publishes the build metadata as the app_build_info gauge of the default Prometheus registry.
+func (p *App) publishBuildInfo() {
+	registerBuildInfoGauge.Do(func() {
+		if err := prometheus.Register(buildInfoGauge); err != nil {
+			logrus.WithError(err).Warn("App build info metric could not be registered")
+		}
+	})
+
+	info := p.BuildInfo()
+	buildInfoGauge.Reset()
+	buildInfoGauge.WithLabelValues(info.Name, info.Version, info.Commit, info.BuildTime, info.GoVersion).Set(1)
+}
@@ -1,44 +1,54 @@
 package app
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"os"
 	"path"
 	"strings"
 
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
 	"github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
-)
-
-const (
-	defaultBasePath = "/"
 )
 
 // Configuration for the App Provider.
 type Config struct {
-	Name     string // Application name.
-	BasePath string // Base path.
+	config.Result
+
+	Name     string `env:"NAME" required:"true"`  // Application name. Defaults to the name of the executable.
+	BasePath string `env:"BASE_PATH" default:"/"` // Base path.
+
+	// Identity of the running instance (see Identity()). On Kubernetes, the pod name and namespace can be set from the downward API.
+	InstanceID  string `env:"INSTANCE_ID"`                 // Unique ID of the running instance. Generated if empty.
+	PodName     string `env:"POD_NAME"`                    // Name of the Kubernetes pod (e.g. from metadata.name).
+	Namespace   string `env:"NAMESPACE"`                   // Kubernetes namespace (e.g. from metadata.namespace).
+	Hostname    string `env:"HOSTNAME"`                    // Hostname of the instance. Defaults to the hostname reported by the kernel.
+	Region      string `env:"REGION"`                      // Region the instance runs in.
+	LogIdentity bool   `env:"LOG_IDENTITY" default:"true"` // Whether or not the identity is added to every log entry.
 }
 
 // Initializes the configuration from environment variables.
 func NewConfigFromEnv() *Config {
-	v := viper.New()
-	v.SetEnvPrefix("APP")
-	v.AutomaticEnv()
-
 	paths := strings.Split(os.Args[0], "/")
-	v.SetDefault("NAME", paths[len(paths)-1])
-	name := v.GetString("NAME")
+	c := &Config{Name: paths[len(paths)-1]}
+	_ = config.Load("APP", c)
+	c.BasePath = path.Clean("/" + c.BasePath)
+	c.resolveIdentity()
 
-	v.SetDefault("BASE_PATH", defaultBasePath)
-	basePath := path.Clean("/" + v.GetString("BASE_PATH"))
+	logrus.WithFields(config.Fields(c)).Debug("App Config initialized")
 
-	logrus.WithFields(logrus.Fields{
-		"name":      name,
-		"base_path": basePath,
-	}).Debug("App Config initialized")
+	return c
+}
 
-	return &Config{
-		Name:     name,
-		BasePath: basePath,
+// Fills the hostname and instance ID of the identity, if they weren't configured.
+func (c *Config) resolveIdentity() {
+	if c.Hostname == "" {
+		c.Hostname, _ = os.Hostname()
+	}
+	if c.InstanceID == "" {
+		id := make([]byte, 8)
+		if _, err := rand.Read(id); err == nil {
+			c.InstanceID = hex.EncodeToString(id)
+		}
 	}
 }
@@ -0,0 +1,146 @@
+package app
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"strings"
+	"sync"
+)
+
+// Identity of the running instance of the application, used to correlate its logs, traces and connections.
+type Identity struct {
+	Name       string // Application name.
+	InstanceID string // Unique ID of the running instance.
+	PodName    string // Name of the Kubernetes pod, if any.
+	Namespace  string // Kubernetes namespace, if any.
+	Hostname   string
+	Region     string
+}
+
+// Returns the identity of the running instance (see the INSTANCE_ID, POD_NAME, NAMESPACE, HOSTNAME and REGION settings).
+func (p *App) Identity() Identity {
+	return Identity{
+		Name:       p.Config.Name,
+		InstanceID: p.Config.InstanceID,
+		PodName:    p.Config.PodName,
+		Namespace:  p.Config.Namespace,
+		Hostname:   p.Config.Hostname,
+		Region:     p.Config.Region,
+	}
+}
+
+// Returns the user agent of the clients of the application, made of its name, version and identity (e.g. "users/1.2.3 (users-5d8f9-x2x7q; namespace=prod)").
+func (p *App) UserAgent() string {
+	identity := p.Identity()
+	details := []string{identity.Instance()}
+	if identity.Namespace != "" {
+		details = append(details, "namespace="+identity.Namespace)
+	}
+	if identity.Region != "" {
+		details = append(details, "region="+identity.Region)
+	}
+	product := identity.Name
+	if version := p.BuildInfo().Version; version != "" {
+		product += "/" + version
+	}
+	return fmt.Sprintf("%s (%s)", product, strings.Join(details, "; "))
+}
+
+// Returns the name of the instance: the pod name on Kubernetes, the hostname or the instance ID otherwise.
+func (i Identity) Instance() string {
+	switch {
+	case i.PodName != "":
+		return i.PodName
+	case i.Hostname != "":
+		return i.Hostname
+	}
+	return i.InstanceID
+}
+
+// Returns the application name followed by the name of the instance (e.g. "users/users-5d8f9-x2x7q").
+// Used to name connections, like the MongoDB appName or the NATS connection name.
+func (i Identity) String() string {
+	if instance := i.Instance(); instance != "" {
+		return i.Name + "/" + instance
+	}
+	return i.Name
+}
+
+// Returns the identity as log fields, leaving out the empty ones.
+func (i Identity) Fields() logrus.Fields {
+	fields := logrus.Fields{}
+	for key, value := range map[string]string{
+		"app":         i.Name,
+		"instance_id": i.InstanceID,
+		"pod":         i.PodName,
+		"namespace":   i.Namespace,
+		"hostname":    i.Hostname,
+		"region":      i.Region,
+	} {
+		if value != "" {
+			fields[key] = value
+		}
+	}
+	return fields
+}
+
+// Returns the identity as tracing tags, named after the OpenTelemetry resource conventions and leaving out the empty ones.
+func (i Identity) Tags() map[string]string {
+	tags := map[string]string{}
+	for key, value := range map[string]string{
+		"service.instance.id": i.InstanceID,
+		"k8s.pod.name":        i.PodName,
+		"k8s.namespace.name":  i.Namespace,
+		"host.name":           i.Hostname,
+		"cloud.region":        i.Region,
+	} {
+		if value != "" {
+			tags[key] = value
+		}
+	}
+	return tags
+}
+
+// Logrus hook adding the identity to every entry of the standard logger, unless the entry already has a field with the same name.
+var identityHook = &fieldsHook{}
+
+type fieldsHook struct {
+	sync.RWMutex
+	fields logrus.Fields
+}
+
+func (h *fieldsHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Replaces the data of the entry with a copy including the fields, as the original data may be shared by entries logged concurrently.
+func (h *fieldsHook) Fire(entry *logrus.Entry) error {
+	h.RLock()
+	defer h.RUnlock()
+
+	if len(h.fields) == 0 {
+		return nil
+	}
+	data := make(logrus.Fields, len(entry.Data)+len(h.fields))
+	for key, value := range h.fields {
+		data[key] = value
+	}
+	for key, value := range entry.Data {
+		data[key] = value
+	}
+	entry.Data = data
+	return nil
+}
+
+var addIdentityHook sync.Once
+
+// Adds the identity to every entry of the logrus standard logger.
+func (p *App) logIdentity() {
+	addIdentityHook.Do(func() {
+		logrus.AddHook(identityHook)
+	})
+
+	identityHook.Lock()
+	identityHook.fields = p.Identity().Fields()
+	identityHook.Unlock()
+}
@@ -1,46 +1,28 @@
 package graphql
 
 import (
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/httpserver"
 	"github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
-)
-
-const (
-	defaultPort             = 3030
-	defaultGraphiQLEndpoint = "/graphiql/"
 )
 
 // Configuration for the GraphQL Provider.
 type Config struct {
-	Port             int    // Port on which to start the HTTP service.
-	GraphiQLEnabled  bool   // Whether or not to enable the GraphiQL endpoint (GUI for GraphQL messages).
-	GraphiQLEndpoint string // Endpoint on which to expose the GraphiQL endpoint.
+	config.Result
+
+	Port             int                `env:"PORT" default:"3030" min:"0" max:"65535"` // Port on which to start the HTTP service.
+	Server           *httpserver.Config // Settings of the HTTP server (timeouts, TLS...). Defaults are used if nil.
+	GraphiQLEnabled  bool               `env:"GRAPHIQL_ENABLED" default:"false"`       // Whether or not to enable the GraphiQL endpoint (GUI for GraphQL messages).
+	GraphiQLEndpoint string             `env:"GRAPHIQL_ENDPOINT" default:"/graphiql/"` // Endpoint on which to expose the GraphiQL endpoint.
 }
 
 // Initializes the configuration from environment variables.
 func NewConfigFromEnv() *Config {
-	v := viper.New()
-	v.SetEnvPrefix("GRAPHQL")
-	v.AutomaticEnv()
-
-	v.SetDefault("PORT", defaultPort)
-	port := v.GetInt("PORT")
-
-	v.SetDefault("GRAPHIQL_ENABLED", false)
-	graphiQlEnabled := v.GetBool("GRAPHIQL_ENABLED")
-
-	v.SetDefault("GRAPHIQL_ENDPOINT", defaultGraphiQLEndpoint)
-	graphiQLEndpoint := v.GetString("GRAPHIQL_ENDPOINT")
+	c := &Config{}
+	_ = config.Load("GRAPHQL", c)
+	c.Server = httpserver.NewConfigFromEnv("GRAPHQL")
 
-	logrus.WithFields(logrus.Fields{
-		"port":              port,
-		"graphiql_enabled":  graphiQlEnabled,
-		"graphiql_endpoint": graphiQLEndpoint,
-	}).Debug("GraphQL Config Initialized")
+	logrus.WithFields(config.Fields(c)).Debug("GraphQL Config Initialized")
 
-	return &Config{
-		Port:             port,
-		GraphiQLEnabled:  graphiQlEnabled,
-		GraphiQLEndpoint: graphiQLEndpoint,
-	}
+	return c
 }
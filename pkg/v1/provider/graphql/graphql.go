@@ -1,29 +1,26 @@
 package graphql
 
 import (
-	"context"
 	"fmt"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
 	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/middleware"
-	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/httpserver"
 	"github.com/friendsofgo/graphiql"
 	"github.com/graph-gophers/graphql-go"
 	"github.com/graph-gophers/graphql-go/relay"
 	"github.com/sirupsen/logrus"
 	"net/http"
-	"time"
 )
 
 // GraphQL Provider.
 // Uses a GraphQL schema file and root resolver to provide a GraphQL API.
 // This schema file must be set before the Run phase.
 type GraphQL struct {
-	provider.AbstractRunProvider
+	httpserver.Server
 
 	Config          *Config
 	schema          *graphql.Schema
 	middlewareChain []middleware.Middleware
-
-	srv *http.Server
 }
 
 // Creates a GraphQL Provider.
@@ -34,6 +31,11 @@ func New(config *Config, middlewareChain ...middleware.Middleware) *GraphQL {
 	}
 }
 
+// Returns the invalid settings of the GraphQL configuration, if any (see provider.Configured).
+func (p *GraphQL) ConfigError() error {
+	return config.Err(p.Config)
+}
+
 // Creates an HTTP service on the configured port (endpoint is always "/"), where queries can be sent.
 // If enabled, also adds a GraphiQL handler to provide a GUI.
 func (p *GraphQL) Run() error {
@@ -41,8 +43,7 @@ func (p *GraphQL) Run() error {
 		return fmt.Errorf("must set GraphQL schema")
 	}
 
-	addr := fmt.Sprintf(":%d", p.Config.Port)
-	logEntry := logrus.WithField("addr", addr)
+	logEntry := logrus.WithField("port", p.Config.Port)
 
 	mux := http.NewServeMux()
 	mux.Handle("/", p.getHandler())
@@ -56,29 +57,7 @@ func (p *GraphQL) Run() error {
 		mux.Handle(p.Config.GraphiQLEndpoint, http.StripPrefix(p.Config.GraphiQLEndpoint, graphiqlHandler))
 	}
 
-	p.srv = &http.Server{Addr: addr, Handler: mux}
-	p.SetRunning(true)
-
-	logEntry.Info("GraphQL Provider launched")
-	if err := p.srv.ListenAndServe(); err != http.ErrServerClosed {
-		logEntry.WithError(err).Error("GraphQL Provider launch failed")
-		return err
-	}
-
-	return nil
-}
-
-func (p *GraphQL) Close() error {
-	if p.srv == nil {
-		return p.AbstractRunProvider.Close()
-	}
-
-	ctx, _ := context.WithTimeout(context.Background(), 1*time.Millisecond)
-	if err := p.srv.Shutdown(ctx); err != nil {
-		logrus.WithError(err).Error("Error while closing GraphQL server")
-	}
-
-	return p.AbstractRunProvider.Close()
+	return p.Serve("GraphQL Provider", p.Config.Port, p.Config.Server, mux, logEntry)
 }
 
 // Allows setting the GraphQL schema file.
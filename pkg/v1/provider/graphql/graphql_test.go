@@ -1,6 +1,7 @@
 package graphql
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.azc.ext.hp.com/hp-business-platform/lib-core-go/pkg/v1/test"
@@ -9,6 +10,7 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/sirupsen/logrus"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"strings"
 	"testing"
@@ -34,9 +36,9 @@ var _ = Describe("GraphQL provider", func() {
 		var p *GraphQL
 		By("Creating and initializing the provider", func() {
 			p = New(&Config{
-				Port:             defaultPort,
+				Port:             0,
 				GraphiQLEnabled:  true,
-				GraphiQLEndpoint: defaultGraphiQLEndpoint,
+				GraphiQLEndpoint: "/graphiql/",
 			})
 			err := p.SetSchema(schema, &resolver{})
 			Expect(err).NotTo(HaveOccurred())
@@ -48,13 +50,15 @@ var _ = Describe("GraphQL provider", func() {
 				err := p.Run()
 				Expect(err).NotTo(HaveOccurred())
 			}()
-			err := provider.WaitForRunningProvider(p, 2*time.Second)
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			err := provider.WaitReady(ctx, p)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(p.IsRunning()).To(BeTrue())
 		})
 		By("Performing a query", func() {
 			query := `{"query": "{ping() {}}"}`
-			resp, err := http.Post(fmt.Sprintf("http://localhost:%d", defaultPort), "application/json", strings.NewReader(query))
+			resp, err := http.Post(fmt.Sprintf("http://localhost:%d", p.Addr().(*net.TCPAddr).Port), "application/json", strings.NewReader(query))
 			Expect(err).NotTo(HaveOccurred())
 			Expect(resp).NotTo(BeNil())
 			Expect(resp.StatusCode).To(Equal(200))
@@ -0,0 +1,77 @@
+package grpc
+
+import (
+	"context"
+	"github.com/grpc-ecosystem/go-grpc-middleware/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strings"
+)
+
+// Authenticates the requests of the GRPC Server (see CustomOpts).
+// Returns the context passed on to the handler (e.g. with the identity of the client),
+// or an error, which is returned with the Unauthenticated code unless it already is a GRPC status.
+type Authenticator interface {
+	Authenticate(ctx context.Context) (context.Context, error)
+}
+
+// Function implementing Authenticator.
+type AuthenticatorFunc func(ctx context.Context) (context.Context, error)
+
+// Calls the function.
+func (f AuthenticatorFunc) Authenticate(ctx context.Context) (context.Context, error) {
+	return f(ctx)
+}
+
+// Implemented by services that authenticate their requests themselves, instead of the Authenticator of the GRPC Server.
+// The skip list (see the AUTH_SKIP_METHODS setting) doesn't apply to them.
+type ServiceAuthFuncOverride = grpc_auth.ServiceAuthFuncOverride
+
+// Authenticator accepting the requests that any of the authenticators accepts, tried in order.
+// Fails with the error of the first authenticator if none of them accepts the request.
+type anyAuthenticator []Authenticator
+
+func (a anyAuthenticator) Authenticate(ctx context.Context) (context.Context, error) {
+	var first error
+	for _, authenticator := range a {
+		authenticated, err := authenticator.Authenticate(ctx)
+		if err == nil {
+			return authenticated, nil
+		}
+		if first == nil {
+			first = err
+		}
+	}
+	return nil, first
+}
+
+// Authenticates requests with the Authenticator, except for the methods of the skip list.
+// All requests are accepted if no Authenticator was configured.
+func (p *Server) authFunc(ctx context.Context) (context.Context, error) {
+	if p.authenticator == nil {
+		return ctx, nil
+	}
+	if method, ok := grpc.Method(ctx); ok && p.skipsAuth(method) {
+		return ctx, nil
+	}
+
+	authenticated, err := p.authenticator.Authenticate(ctx)
+	if err != nil {
+		if _, ok := status.FromError(err); !ok {
+			err = status.Error(codes.Unauthenticated, err.Error())
+		}
+		return nil, err
+	}
+	return authenticated, nil
+}
+
+// Whether the method is in the skip list, either by its full name or by the name of its service (e.g. "/grpc.health.v1.Health/").
+func (p *Server) skipsAuth(method string) bool {
+	for _, skipped := range p.Config.AuthSkipMethods {
+		if method == skipped || (strings.HasSuffix(skipped, "/") && strings.HasPrefix(method, skipped)) {
+			return true
+		}
+	}
+	return false
+}
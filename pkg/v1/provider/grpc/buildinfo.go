@@ -0,0 +1,67 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/app"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/ptypes/empty"
+	structpb "github.com/golang/protobuf/ptypes/struct"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+// Full name of the method publishing the build metadata of the application.
+const BuildInfoMethod = "/hpbp.provider.v1.BuildInfo/GetBuildInfo"
+
+// GRPC service publishing the build metadata of the application (see app.BuildInfo), as a google.protobuf.Struct.
+type buildInfoServer interface {
+	GetBuildInfo(ctx context.Context, req *empty.Empty) (*structpb.Struct, error)
+}
+
+type buildInfoService struct {
+	appProvider *app.App
+}
+
+// Returns the build metadata, with the same fields as the /version endpoint of the Status Provider.
+func (s *buildInfoService) GetBuildInfo(ctx context.Context, req *empty.Empty) (*structpb.Struct, error) {
+	b, err := json.Marshal(s.appProvider.BuildInfo())
+	if err != nil {
+		return nil, err
+	}
+	info := &structpb.Struct{}
+	if err := jsonpb.UnmarshalString(string(b), info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+var buildInfoServiceDesc = grpc.ServiceDesc{
+	ServiceName: "hpbp.provider.v1.BuildInfo",
+	HandlerType: (*buildInfoServer)(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "GetBuildInfo",
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			req := &empty.Empty{}
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			if interceptor == nil {
+				return srv.(buildInfoServer).GetBuildInfo(ctx, req)
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: BuildInfoMethod}
+			return interceptor(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.(buildInfoServer).GetBuildInfo(ctx, req.(*empty.Empty))
+			})
+		},
+	}},
+}
+
+func (p *Server) registerBuildInfoService() {
+	if p.appProvider == nil {
+		logrus.Debug("GRPC Server build info service disabled, no App Provider found")
+		return
+	}
+	p.Server.RegisterService(&buildInfoServiceDesc, &buildInfoService{appProvider: p.appProvider})
+	logrus.Debug("GRPC Server build info service registered")
+}
@@ -1,45 +1,48 @@
 package grpc
 
 import (
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/grpc/payload"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/tlsconfig"
 	"github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
-)
-
-const (
-	defaultPort = 3000
+	"time"
 )
 
 // Configuration for the GRPC Server Provider.
 type Config struct {
-	Port         int  // Port on which to start the GRPC service.
-	LogPayload   bool // Whether or not to enable logging of the payload. Should be disabled on production.
-	EnableHealth bool // Whether or not to register the health endpoint.
+	config.Result
+
+	Port                         int               `env:"PORT" default:"3000" min:"0" max:"65535"`                                                        // Port on which to start the GRPC service.
+	LogPayload                   bool              `env:"LOG_PAYLOAD" default:"false"`                                                                    // Whether or not to enable logging of the payload. Should be disabled on production.
+	EnableHealth                 bool              `env:"HEALTH_ENABLED" default:"true"`                                                                  // Whether or not to register the health endpoint.
+	ValidateRequests             bool              `env:"VALIDATE_REQUESTS" default:"false"`                                                              // Whether or not to validate the requests implementing Validate() or ValidateAll() (e.g. generated by protoc-gen-validate) before they reach the handlers. Disabled by default, as enabling it rejects requests that services used to accept.
+	HealthCheckInterval          time.Duration     `env:"HEALTH_CHECK_INTERVAL" default:"5" min:"0"`                                                      // How often the health status is updated from the probes. Zero only sets it once the server starts.
+	SocketName                   string            `env:"SOCKET_NAME"`                                                                                    // Name of the systemd socket to serve on when socket activated (see FileDescriptorName= in systemd.socket), instead of binding the port.
+	AuthSkipMethods              []string          `env:"AUTH_SKIP_METHODS" default:"/grpc.health.v1.Health/,/grpc.reflection.v1alpha.ServerReflection/"` // Comma separated methods (e.g. /api.PingService/Ping) or services (e.g. /grpc.health.v1.Health/) that don't require authentication.
+	MaxRecvMsgSize               int               `env:"MAX_RECV_MSG_SIZE" default:"4194304" min:"0"`                                                    // Maximum size of a received message in bytes. Zero uses the GRPC default (4 MiB).
+	MaxSendMsgSize               int               `env:"MAX_SEND_MSG_SIZE" default:"0" min:"0"`                                                          // Maximum size of a sent message in bytes. Zero uses the GRPC default (no limit).
+	MaxConcurrentStreams         uint32            `env:"MAX_CONCURRENT_STREAMS" default:"0"`                                                             // Maximum number of concurrent streams (calls) per connection. Zero means no limit.
+	WriteBufferSize              int               `env:"WRITE_BUFFER_SIZE" default:"32768" min:"0"`                                                      // Size of the write buffer of connections in bytes. Zero uses the GRPC default.
+	ReadBufferSize               int               `env:"READ_BUFFER_SIZE" default:"32768" min:"0"`                                                       // Size of the read buffer of connections in bytes. Zero uses the GRPC default.
+	KeepaliveTime                time.Duration     `env:"KEEPALIVE_TIME" default:"7200" min:"0"`                                                          // How long a connection is idle before the server pings the client to check it is alive. Zero uses the GRPC default (2 hours).
+	KeepaliveTimeout             time.Duration     `env:"KEEPALIVE_TIMEOUT" default:"20" min:"0"`                                                         // How long the server waits for the response to a ping before closing the connection. Zero uses the GRPC default (20 seconds).
+	KeepaliveMinTime             time.Duration     `env:"KEEPALIVE_MIN_TIME" default:"10" min:"0"`                                                        // Minimum interval between the pings of clients, more frequent pings close the connection. Zero uses the GRPC default (5 minutes).
+	KeepalivePermitWithoutStream bool              `env:"KEEPALIVE_PERMIT_WITHOUT_STREAM" default:"true"`                                                 // Whether or not clients may ping without active streams, like the GRPC Connection Provider does.
+	MaxConnectionIdle            time.Duration     `env:"MAX_CONNECTION_IDLE" default:"0" min:"0"`                                                        // How long a connection may stay without streams before it is closed. Zero means no limit.
+	MaxConnectionAge             time.Duration     `env:"MAX_CONNECTION_AGE" default:"0" min:"0"`                                                         // How long a connection may exist before it is closed gracefully, so clients reconnect (and get balanced over new instances). Zero means no limit.
+	MaxConnectionAgeGrace        time.Duration     `env:"MAX_CONNECTION_AGE_GRACE" default:"0" min:"0"`                                                   // How long pending calls may take once a connection reached its maximum age. Zero means no limit.
+	TLS                          *tlsconfig.Config // TLS (and mTLS) configuration. The server uses plaintext if nil or disabled.
+	Payload                      *payload.Policy   // Policy deciding which payloads are logged, and how. Defaults are used if nil.
 }
 
 // Initializes the configuration from environment variables.
 func NewConfigFromEnv() *Config {
-	v := viper.New()
-	v.SetEnvPrefix("GRPC")
-	v.AutomaticEnv()
-
-	v.SetDefault("PORT", defaultPort)
-	port := v.GetInt("PORT")
-
-	v.SetDefault("LOG_PAYLOAD", false)
-	logPayload := v.GetBool("LOG_PAYLOAD")
-
-	v.SetDefault("HEALTH_ENABLED", true)
-	enableHealth := v.GetBool("HEALTH_ENABLED")
+	c := &Config{}
+	_ = config.Load("GRPC", c)
+	c.TLS = tlsconfig.NewConfigFromEnv("GRPC")
+	c.Payload = payload.NewPolicyFromEnv()
 
-	logrus.WithFields(logrus.Fields{
-		"port":         port,
-		"logPayload":   logPayload,
-		"enableHealth": enableHealth,
-	}).Debug("Server Config Initialized")
+	logrus.WithFields(config.Fields(c)).Debug("Server Config Initialized")
 
-	return &Config{
-		Port:         port,
-		LogPayload:   logPayload,
-		EnableHealth: enableHealth,
-	}
+	return c
 }
@@ -1,63 +1,39 @@
 package connection
 
 import (
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/grpc/payload"
 	"github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
-)
-
-const (
-	defaultHost = "127.0.0.1"
-	defaultPort = 3000
 )
 
 // Configuration for the GRPC Connection Provider.
 type Config struct {
-	Prefix       string // GRPC Connection prefix, used for environment variables and in some bits of logging (like health).
-	Host         string // Host on which to connect to the GRPC service.
-	Port         int    // Port on which to connect to the GRPC service.
-	LogPayload   bool   // Whether or not to enable logging of the payload. Should be disabled on production.
-	EnableHealth bool   // Whether or not to enable checking the health of the connection.
+	config.Result
+
+	Prefix       string          // GRPC Connection prefix, used for environment variables and in some bits of logging (like health).
+	Host         string          `env:"HOST"`                                    // Host on which to connect to the GRPC service. Defaults to FIT_STATION_HOST, or 127.0.0.1.
+	Port         int             `env:"PORT" default:"3000" min:"1" max:"65535"` // Port on which to connect to the GRPC service.
+	LogPayload   bool            `env:"LOG_PAYLOAD" default:"false"`             // Whether or not to enable logging of the payload. Should be disabled on production.
+	EnableHealth bool            `env:"HEALTH_ENABLED" default:"true"`           // Whether or not to enable checking the health of the connection.
+	Payload      *payload.Policy // Policy deciding which payloads are logged, and how. Defaults are used if nil.
+}
+
+// Host shared by all GRPC Connections, unless overridden by their own HOST setting.
+type fitStation struct {
+	Host string `env:"HOST" default:"127.0.0.1"` // Host on which to connect to the GRPC services.
 }
 
 // Initializes the configuration from environment variables.
 func NewConfigFromEnv(prefix string) *Config {
-	fsv := viper.New()
-	fsv.SetEnvPrefix("FIT_STATION")
-	fsv.AutomaticEnv()
-
-	v := viper.New()
-	v.SetEnvPrefix(prefix)
-	v.AutomaticEnv()
-
-	hostDefault := defaultHost
-	if host := fsv.GetString("HOST"); host != "" {
-		hostDefault = host
-	}
-	v.SetDefault("HOST", hostDefault)
-	host := v.GetString("HOST")
-
-	v.SetDefault("PORT", defaultPort)
-	port := v.GetInt("PORT")
-
-	v.SetDefault("LOG_PAYLOAD", false)
-	logPayload := v.GetBool("LOG_PAYLOAD")
-
-	v.SetDefault("HEALTH_ENABLED", true)
-	enableHealth := v.GetBool("HEALTH_ENABLED")
-
-	logrus.WithFields(logrus.Fields{
-		"prefix":       prefix,
-		"host":         host,
-		"port":         port,
-		"logPayload":   logPayload,
-		"enableHealth": enableHealth,
-	}).Debug("GRPC Connection Config initialized")
-
-	return &Config{
-		Prefix:       prefix,
-		Host:         host,
-		Port:         port,
-		LogPayload:   logPayload,
-		EnableHealth: enableHealth,
-	}
+	fs := &fitStation{}
+	fsErr := config.Load("FIT_STATION", fs)
+
+	c := &Config{Prefix: prefix, Host: fs.Host}
+	_ = config.Load(prefix, c)
+	config.Keep(c, fsErr)
+	c.Payload = payload.NewPolicyFromEnv()
+
+	logrus.WithFields(config.Fields(c)).WithField("prefix", prefix).Debug("GRPC Connection Config initialized")
+
+	return c
 }
@@ -3,9 +3,12 @@ package connection
 import (
 	"context"
 	"fmt"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
 	"time"
 
 	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/app"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/grpc/payload"
 	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/probes"
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	grpc_logrus "github.com/grpc-ecosystem/go-grpc-middleware/logging/logrus"
@@ -26,6 +29,7 @@ type Connection struct {
 	Conn           *grpc.ClientConn
 	Health         grpc_health_v1.HealthClient
 	probesProvider *probes.Probes
+	appProvider    *app.App
 }
 
 // Creates a GRPC Connection Provider.
@@ -36,6 +40,28 @@ func New(config *Config, probesProvider *probes.Probes) *Connection {
 	}
 }
 
+// Returns the invalid settings of the GRPC Connection configuration, if any (see provider.Configured).
+func (p *Connection) ConfigError() error {
+	return config.Err(p.Config)
+}
+
+// GRPC Connection depends on the (optional) Probes and App Providers.
+func (p *Connection) Dependencies() []provider.Provider {
+	return []provider.Provider{p.probesProvider, p.appProvider}
+}
+
+// Looks up the optional Probes Provider in the Stack if it wasn't passed to New(),
+// and the optional App Provider, used to send the identity of the instance as user agent (see app.App.UserAgent()).
+func (p *Connection) Resolve(registry provider.Registry) error {
+	if p.probesProvider == nil {
+		registry.Lookup(&p.probesProvider)
+	}
+	if p.appProvider == nil {
+		registry.Lookup(&p.appProvider)
+	}
+	return nil
+}
+
 // Establishes the gRPC connection.
 func (p *Connection) Init() error {
 	addr := fmt.Sprintf("%s:%d", p.Config.Host, p.Config.Port)
@@ -63,20 +89,26 @@ func (p *Connection) Init() error {
 		grpc_logrus.StreamClientInterceptor(logEntry, logOpts...),
 	}
 
-	// Payload is only logged by the server if it was configured to do so.
+	// Payload is only logged by the server if it was configured to do so, following the payload logging policy.
 	if p.Config.LogPayload {
-		unaryInterceptors = append(unaryInterceptors, grpc_logrus.PayloadUnaryClientInterceptor(logEntry, p.logDeciderFunc))
-		streamInterceptors = append(streamInterceptors, grpc_logrus.PayloadStreamClientInterceptor(logEntry, p.logDeciderFunc))
+		payloadLogger := payload.NewLogger(logEntry, p.Config.Payload)
+		unaryInterceptors = append(unaryInterceptors, payloadLogger.UnaryClientInterceptor())
+		streamInterceptors = append(streamInterceptors, payloadLogger.StreamClientInterceptor())
 	}
 
-	conn, err := grpc.DialContext(context.Background(), addr,
+	dialOpts := []grpc.DialOption{
 		grpc.WithInsecure(),
 		grpc.WithKeepaliveParams(keepalive.ClientParameters{
 			PermitWithoutStream: true,
 		}),
 		grpc.WithUnaryInterceptor(grpc_middleware.ChainUnaryClient(unaryInterceptors...)),
 		grpc.WithStreamInterceptor(grpc_middleware.ChainStreamClient(streamInterceptors...)),
-	)
+	}
+	if p.appProvider != nil {
+		dialOpts = append(dialOpts, grpc.WithUserAgent(p.appProvider.UserAgent()))
+	}
+
+	conn, err := grpc.DialContext(context.Background(), addr, dialOpts...)
 	if err != nil {
 		logEntry.WithError(err).Error("GRPC connection could not be created")
 		return err
@@ -123,11 +155,6 @@ func (p *Connection) CheckHealth(ctx context.Context) error {
 	return nil
 }
 
-func (p *Connection) logDeciderFunc(ctx context.Context, fullMethodName string) bool {
-	// TODO: Should we really log everything?
-	return true
-}
-
 func (p *Connection) initHealthClient() {
 	if !p.Config.EnableHealth {
 		logrus.WithField("service", p.Config.Prefix).Debug("GRPC Connection health disabled.")
@@ -6,11 +6,14 @@ import (
 	"github.azc.ext.hp.com/hp-business-platform/lib-core-go/pkg/v1/test"
 	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/examples/ping/server/gen"
 	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/app"
 	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/grpc"
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/logrus/ctxlogrus"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/metadata"
+	"strings"
 	"testing"
 	"time"
 )
@@ -38,7 +41,9 @@ var _ = Describe("GRPC connection provider test", func() {
 			err := server.Run()
 			Expect(err).NotTo(HaveOccurred())
 		}()
-		err = provider.WaitForRunningProvider(server, 2*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		err = provider.WaitReady(ctx, server)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(server.IsRunning()).To(BeTrue())
 	})
@@ -54,7 +59,7 @@ var _ = Describe("GRPC connection provider test", func() {
 
 			By("Creating and initializing the provider", func() {
 				p = New(&Config{
-					Host:         defaultHost,
+					Host:         "127.0.0.1",
 					Port:         3030,
 					LogPayload:   true,
 					EnableHealth: true,
@@ -85,6 +90,16 @@ var _ = Describe("GRPC connection provider test", func() {
 				Expect(err).ToNot(HaveOccurred())
 			})
 		})
+		It("Sends the identity of the instance as user agent", func() {
+			p := New(&Config{Host: "127.0.0.1", Port: 3030}, nil)
+			p.appProvider = app.New(&app.Config{Name: "client", PodName: "client-5d8f9-x2x7q", Namespace: "prod"})
+			Expect(p.Init()).To(Succeed())
+			defer p.Close()
+
+			res, err := gen.NewPingServiceClient(p.Conn).Ping(context.Background(), &gen.PingRequest{In: "user-agent"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Out).To(MatchRegexp(`^client(/\S+)? \(client-5d8f9-x2x7q; namespace=prod\) grpc-go/`))
+		})
 	})
 })
 
@@ -103,5 +118,10 @@ func (s TestService) Ping(ctx context.Context, request *gen.PingRequest) (*gen.P
 		return nil, errors.New("please error me")
 	}
 
+	if request.In == "user-agent" {
+		md, _ := metadata.FromIncomingContext(ctx)
+		return &gen.PingResponse{Out: strings.Join(md.Get("user-agent"), ",")}, nil
+	}
+
 	return &gen.PingResponse{Out: request.In}, nil
 }
@@ -1,45 +1,33 @@
 package gateway
 
 import (
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/grpc/payload"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/httpserver"
 	"github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
-)
-
-const (
-	defaultPort = 8080
+	"time"
 )
 
 // Configuration for the GRPC Gateway Provider.
 type Config struct {
-	Enabled    bool // Whether or not to enable the gateway.
-	Port       int  // Port on which to start the HTTP service.
-	LogPayload bool // Whether or not to enable logging of the payload. Should be disabled on production.
+	config.Result
+
+	Enabled    bool               `env:"ENABLED" default:"false"`                 // Whether or not to enable the gateway.
+	Port       int                `env:"PORT" default:"8080" min:"0" max:"65535"` // Port on which to start the HTTP service.
+	LogPayload bool               `env:"LOG_PAYLOAD" default:"false"`             // Whether or not to enable logging of the payload. Should be disabled on production.
+	WaitServer time.Duration      `env:"WAIT_SERVER" default:"2" min:"0"`         // Maximum duration to wait for the GRPC Server to run, when the Gateway isn't run by a Stack (which runs the server first). Zero doesn't wait.
+	Server     *httpserver.Config // Settings of the HTTP server (timeouts, TLS...). Defaults are used if nil.
+	Payload    *payload.Policy    // Policy deciding which payloads are logged, and how. Defaults are used if nil.
 }
 
 // Initializes the configuration from environment variables.
 func NewConfigFromEnv() *Config {
-	v := viper.New()
-	v.SetEnvPrefix("GRPC_GATEWAY")
-	v.AutomaticEnv()
-
-	v.SetDefault("ENABLED", false)
-	enabled := v.GetBool("ENABLED")
-
-	v.SetDefault("PORT", defaultPort)
-	port := v.GetInt("PORT")
-
-	v.SetDefault("LOG_PAYLOAD", false)
-	logPayload := v.GetBool("LOG_PAYLOAD")
+	c := &Config{}
+	_ = config.Load("GRPC_GATEWAY", c)
+	c.Server = httpserver.NewConfigFromEnv("GRPC_GATEWAY")
+	c.Payload = payload.NewPolicyFromEnv()
 
-	logrus.WithFields(logrus.Fields{
-		"enabled":    enabled,
-		"port":       port,
-		"logPayload": logPayload,
-	}).Debug("Gateway Config Initialized")
+	logrus.WithFields(config.Fields(c)).Debug("Gateway Config Initialized")
 
-	return &Config{
-		Enabled:    enabled,
-		Port:       port,
-		LogPayload: logPayload,
-	}
+	return c
 }
@@ -0,0 +1,58 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+	"net/http"
+)
+
+// JSON body of requests that failed validation, following the JSON mapping of google.rpc.Status.
+type validationErrorBody struct {
+	Code    int32                 `json:"code"`
+	Message string                `json:"message"`
+	Details []badRequestErrorBody `json:"details"`
+}
+
+type badRequestErrorBody struct {
+	Type            string               `json:"@type"`
+	FieldViolations []fieldViolationBody `json:"field_violations"`
+}
+
+type fieldViolationBody struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+}
+
+// Renders the errors of the GRPC Server like the default handler, except for the requests that failed validation (see grpc.ValidationError()):
+// their field violations are rendered as JSON with the 400 Bad Request status, whatever marshaler the request uses.
+func protoErrorHandler(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	st, ok := status.FromError(err)
+	if !ok {
+		runtime.DefaultHTTPProtoErrorHandler(ctx, mux, marshaler, w, r, err)
+		return
+	}
+	var details []badRequestErrorBody
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			body := badRequestErrorBody{Type: "type.googleapis.com/google.rpc.BadRequest", FieldViolations: []fieldViolationBody{}}
+			for _, violation := range badRequest.GetFieldViolations() {
+				body.FieldViolations = append(body.FieldViolations, fieldViolationBody{Field: violation.GetField(), Description: violation.GetDescription()})
+			}
+			details = append(details, body)
+		}
+	}
+	if len(details) == 0 {
+		runtime.DefaultHTTPProtoErrorHandler(ctx, mux, marshaler, w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	if err := json.NewEncoder(w).Encode(validationErrorBody{Code: int32(st.Code()), Message: st.Message(), Details: details}); err != nil {
+		logrus.WithError(err).Warn("Error while writing GRPC Gateway validation error")
+	}
+}
@@ -3,9 +3,12 @@ package gateway
 import (
 	"context"
 	"fmt"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
 	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider"
 	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/app"
 	server "github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/grpc"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/grpc/payload"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/httpserver"
 	"github.com/grpc-ecosystem/go-grpc-middleware"
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/logrus"
 	"github.com/grpc-ecosystem/go-grpc-middleware/tracing/opentracing"
@@ -13,7 +16,6 @@ import (
 	"github.com/grpc-ecosystem/grpc-gateway/runtime"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
-	"net/http"
 	"time"
 )
 
@@ -21,14 +23,13 @@ import (
 // Provides a gateway that allows clients to perform REST calls to the GRPC server.
 // Needs to know the individual GRPC providers to know where to send its messages.
 type Gateway struct {
-	provider.AbstractRunProvider
+	httpserver.Server
 
 	Config      *Config
 	grpcSrv     *server.Server
 	appProvider *app.App
 
 	client *grpc.ClientConn
-	srv    *http.Server
 	mux    *runtime.ServeMux
 }
 
@@ -42,28 +43,51 @@ func New(config *Config, grpcSrv *server.Server, appProvider *app.App) *Gateway
 	}
 }
 
+// Returns the invalid settings of the GRPC Gateway configuration, if any (see provider.Configured).
+func (p *Gateway) ConfigError() error {
+	return config.Err(p.Config)
+}
+
+// The Gateway depends on the GRPC Server (which needs to be running before the Gateway can connect to it) and the App Provider.
+func (p *Gateway) Dependencies() []provider.Provider {
+	return []provider.Provider{p.grpcSrv, p.appProvider}
+}
+
+// Looks up the GRPC Server and App Providers in the Stack, if they weren't passed to New().
+func (p *Gateway) Resolve(registry provider.Registry) error {
+	if p.grpcSrv == nil && !registry.Lookup(&p.grpcSrv) {
+		return fmt.Errorf("GRPC Gateway Provider requires a GRPC Server Provider")
+	}
+	if p.appProvider == nil && !registry.Lookup(&p.appProvider) {
+		return fmt.Errorf("GRPC Gateway Provider requires an App Provider")
+	}
+	return nil
+}
+
+// Connects to the GRPC Server and creates an HTTP service on the configured port, which forwards REST calls to that server.
+// The Stack runs the GRPC Server first since it is one of the Gateway's dependencies, otherwise the Gateway waits for it to run (see WAIT_SERVER).
 func (p *Gateway) Run() error {
 	if !p.Config.Enabled {
 		logrus.Info("GRPC Gateway Provider not enabled")
 		return nil
 	}
 
-	if err := provider.WaitForRunningProvider(p.grpcSrv, 2); err != nil {
-		return err
+	ctx, cancel := context.WithTimeout(context.Background(), p.Config.WaitServer)
+	defer cancel()
+	if err := provider.WaitReady(ctx, p.grpcSrv); err != nil {
+		return fmt.Errorf("GRPC Gateway Provider requires a running GRPC Server: %w", err)
 	}
 
 	basePath := p.appProvider.ParsePath()
-	serverAddr := p.grpcSrv.Listener.Addr().String()
-	addr := fmt.Sprintf(":%d", p.Config.Port)
+	serverAddr := p.grpcSrv.Addr().String()
 
 	logEntry := logrus.WithFields(logrus.Fields{
 		"basePath":   basePath,
 		"serverAddr": serverAddr,
-		"addr":       addr,
+		"port":       p.Config.Port,
 	})
 
 	jsonPbMarshaller := server.NewJsonPbMarshaller()
-	grpc_logrus.JsonPbMarshaller = jsonPbMarshaller
 	opts := []grpc_logrus.Option{
 		grpc_logrus.WithDurationField(func(duration time.Duration) (key string, value interface{}) {
 			return "grpc.time_ns", duration.Nanoseconds()
@@ -82,16 +106,17 @@ func (p *Gateway) Run() error {
 		grpc_logrus.StreamClientInterceptor(logEntry, opts...),
 	}
 
-	// Payload is only logged by the server if it was configured to do so.
+	// Payload is only logged by the server if it was configured to do so, following the payload logging policy.
 	if p.Config.LogPayload {
-		unaryInterceptors = append(unaryInterceptors, grpc_logrus.PayloadUnaryClientInterceptor(logEntry, p.logDeciderFunc))
-		streamInterceptors = append(streamInterceptors, grpc_logrus.PayloadStreamClientInterceptor(logEntry, p.logDeciderFunc))
+		payloadLogger := payload.NewLogger(logEntry, p.Config.Payload)
+		unaryInterceptors = append(unaryInterceptors, payloadLogger.UnaryClientInterceptor())
+		streamInterceptors = append(streamInterceptors, payloadLogger.StreamClientInterceptor())
 	}
 
 	conn, err := grpc.DialContext(
 		context.Background(),
 		serverAddr,
-		grpc.WithInsecure(),
+		p.grpcSrv.LoopbackDialOption(),
 		grpc.WithUnaryInterceptor(grpc_middleware.ChainUnaryClient(unaryInterceptors...)),
 		grpc.WithStreamInterceptor(grpc_middleware.ChainStreamClient(streamInterceptors...)),
 	)
@@ -102,29 +127,23 @@ func (p *Gateway) Run() error {
 
 	p.mux = runtime.NewServeMux(
 		runtime.WithMarshalerOption(runtime.MIMEWildcard, &jsonPbMarshaller.JSONPb),
-		runtime.WithProtoErrorHandler(runtime.DefaultHTTPProtoErrorHandler),
+		runtime.WithProtoErrorHandler(protoErrorHandler),
 	)
 
 	p.client = conn
-	p.srv = &http.Server{Addr: addr, Handler: NewMuxWrapper(basePath, p.mux)}
-	p.SetRunning(true)
-
-	logEntry.Info("GRPC Gateway Provider launched")
-	if err := p.srv.ListenAndServe(); err != http.ErrServerClosed {
-		logEntry.WithError(err).Error("GRPC Gateway Provider launch failed")
-		return err
-	}
-
-	return nil
+	return p.Serve("GRPC Gateway Provider", p.Config.Port, p.Config.Server, NewMuxWrapper(basePath, p.mux), logEntry)
 }
 
 // Used to register the GRPC providers.
 // The Gateway isn't able to use the same reflection based functionality as the GRPC Provider, therefor this is needed.
+// Waits (for up to 2 seconds) for the Gateway to run, since it connects to the GRPC Server as it is launched.
 func (p *Gateway) RegisterServices(functions ...func(context.Context, *runtime.ServeMux, *grpc.ClientConn) error) error {
 	if !p.Config.Enabled {
 		return nil
 	}
-	if err := provider.WaitForRunningProvider(p.grpcSrv, 2); err != nil {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := provider.WaitReady(ctx, p); err != nil {
 		return err
 	}
 
@@ -136,26 +155,21 @@ func (p *Gateway) RegisterServices(functions ...func(context.Context, *runtime.S
 	return nil
 }
 
-// Closes the connection to the GRPC Provider.
-func (p *Gateway) Close() error {
-	if !p.Config.Enabled || p.client == nil {
-		return p.AbstractRunProvider.Close()
-	}
-
-	ctx, _ := context.WithTimeout(context.Background(), 1*time.Millisecond)
-	if err := p.srv.Shutdown(ctx); err != nil {
-		logrus.WithError(err).Error("Error while closing GRPC Gateway REST server")
-		return err
-	}
-	if err := p.client.Close(); err != nil {
-		logrus.WithError(err).Error("Error while closing GRPC Gateway connection to server")
-		return err
+// Gracefully shuts down the REST server (see httpserver.Server) and closes the connection to the GRPC Provider.
+func (p *Gateway) CloseContext(ctx context.Context) error {
+	err := p.Server.CloseContext(ctx)
+	if p.client != nil {
+		if closeErr := p.client.Close(); closeErr != nil {
+			logrus.WithError(closeErr).Error("Error while closing GRPC Gateway connection to server")
+			if err == nil {
+				err = closeErr
+			}
+		}
 	}
-
-	return p.AbstractRunProvider.Close()
+	return err
 }
 
-func (p *Gateway) logDeciderFunc(ctx context.Context, fullMethodName string) bool {
-	// TODO: Should we really log everything?
-	return true
+// Closes the REST server and the connection to the GRPC Provider, waiting for in-flight requests to finish.
+func (p *Gateway) Close() error {
+	return p.CloseContext(context.Background())
 }
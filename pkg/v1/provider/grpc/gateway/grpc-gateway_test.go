@@ -13,16 +13,11 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/sirupsen/logrus"
+	"io/ioutil"
+	"net"
 	"net/http"
-	"reflect"
-	"sync/atomic"
 	"testing"
 	"time"
-	"unsafe"
-)
-
-const (
-	defaultPortTest = 8888
 )
 
 func TestGRPCGateway(t *testing.T) {
@@ -36,8 +31,9 @@ var _ = Describe("GRPC gateway provider test", func() {
 
 	BeforeSuite(func() {
 		server = grpc.New(&grpc.Config{
-			Port:       3030,
-			LogPayload: true,
+			Port:             0,
+			LogPayload:       true,
+			ValidateRequests: true,
 		})
 		err := server.Init()
 		Expect(err).NotTo(HaveOccurred())
@@ -46,7 +42,9 @@ var _ = Describe("GRPC gateway provider test", func() {
 			err := server.Run()
 			Expect(err).NotTo(HaveOccurred())
 		}()
-		err = provider.WaitForRunningProvider(server, 2*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		err = provider.WaitReady(ctx, server)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(server.IsRunning()).To(BeTrue())
 	})
@@ -57,7 +55,7 @@ var _ = Describe("GRPC gateway provider test", func() {
 
 		By("Creating and initializing the provider", func() {
 			p = New(&Config{
-				Port:       defaultPortTest,
+				Port:       0,
 				LogPayload: true,
 				Enabled:    true,
 			}, server, app.New(&app.Config{}))
@@ -70,7 +68,9 @@ var _ = Describe("GRPC gateway provider test", func() {
 				err := p.Run()
 				Expect(err).NotTo(HaveOccurred())
 			}()
-			err := provider.WaitForRunningProvider(p, 2*time.Second)
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			err := provider.WaitReady(ctx, p)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(p.IsRunning()).To(BeTrue())
 		})
@@ -79,7 +79,7 @@ var _ = Describe("GRPC gateway provider test", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 		By("Calling the gateway", func() {
-			res, err := http.Get(fmt.Sprintf("http://localhost:%d/ping", defaultPortTest))
+			res, err := http.Get(fmt.Sprintf("http://localhost:%d/ping", p.Addr().(*net.TCPAddr).Port))
 			Expect(err).NotTo(HaveOccurred())
 			Expect(res).NotTo(BeNil())
 			//Expect(res.StatusCode).To(Equal(200))
@@ -87,7 +87,6 @@ var _ = Describe("GRPC gateway provider test", func() {
 		By("Shutting down the gateway", func() {
 			err := p.Close()
 			Expect(err).ToNot(HaveOccurred())
-			resetHTTPServer(p)
 		})
 	})
 	It("Runs the GRPC gateway (with a basePath)", func() {
@@ -96,7 +95,7 @@ var _ = Describe("GRPC gateway provider test", func() {
 
 		By("Creating and initializing the provider", func() {
 			p = New(&Config{
-				Port:       defaultPortTest,
+				Port:       0,
 				LogPayload: true,
 				Enabled:    true,
 			}, server, app.New(&app.Config{
@@ -111,7 +110,9 @@ var _ = Describe("GRPC gateway provider test", func() {
 				err := p.Run()
 				Expect(err).NotTo(HaveOccurred())
 			}()
-			err := provider.WaitForRunningProvider(p, 2*time.Second)
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			err := provider.WaitReady(ctx, p)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(p.IsRunning()).To(BeTrue())
 		})
@@ -120,7 +121,7 @@ var _ = Describe("GRPC gateway provider test", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 		By("Calling the gateway", func() {
-			res, err := http.Get(fmt.Sprintf("http://localhost:%d/srv/api/ping", defaultPortTest))
+			res, err := http.Get(fmt.Sprintf("http://localhost:%d/srv/api/ping", p.Addr().(*net.TCPAddr).Port))
 			Expect(err).NotTo(HaveOccurred())
 			Expect(res).NotTo(BeNil())
 			//Expect(res.StatusCode).To(Equal(200))
@@ -128,23 +129,73 @@ var _ = Describe("GRPC gateway provider test", func() {
 		By("Shutting down the gateway", func() {
 			err := p.Close()
 			Expect(err).ToNot(HaveOccurred())
-			resetHTTPServer(p)
 		})
 	})
-})
+	It("Waits for the GRPC Server to run", func() {
+		late := grpc.New(&grpc.Config{Port: 0})
+		Expect(late.Init()).To(Succeed())
+		p := New(&Config{Port: 0, Enabled: true, WaitServer: 2 * time.Second}, late, app.New(&app.Config{}))
+		Expect(p.Init()).To(Succeed())
+		go func() {
+			defer GinkgoRecover()
+			Expect(p.Run()).To(Succeed())
+		}()
+		go func() {
+			defer GinkgoRecover()
+			time.Sleep(100 * time.Millisecond)
+			Expect(late.Run()).To(Succeed())
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		Expect(provider.WaitReady(ctx, p)).To(Succeed())
+		Expect(p.Close()).To(Succeed())
+		Expect(late.Close()).To(Succeed())
+	})
+	It("Renders the field violations of invalid requests", func() {
+		p := New(&Config{Port: 0, Enabled: true}, server, app.New(&app.Config{}))
+		Expect(p.Init()).To(Succeed())
+		go func() {
+			defer GinkgoRecover()
+			Expect(p.Run()).To(Succeed())
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		Expect(provider.WaitReady(ctx, p)).To(Succeed())
+		defer func() {
+			Expect(p.Close()).To(Succeed())
+		}()
+		Expect(p.RegisterServices(gen.RegisterPingServiceHandler)).To(Succeed())
 
-// Resets the shutdown state of the HTTP REST grpcSrv used by the Gateway, allowing it be be used again.
-// It does this by using an unsafe pointer to the "inShutdown" field of http.Server and setting it to it's original value (0).
-func resetHTTPServer(p *Gateway) {
-	pv := reflect.ValueOf(p.srv)
-	fv := reflect.Indirect(pv).FieldByName("inShutdown")
-	fp := unsafe.Pointer(fv.UnsafeAddr())
-	atomic.StoreInt32((*int32)(fp), 0)
-}
+		res, err := http.Get(fmt.Sprintf("http://localhost:%d/ping?in=invalid", p.Addr().(*net.TCPAddr).Port))
+		Expect(err).NotTo(HaveOccurred())
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+		body, err := ioutil.ReadAll(res.Body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(body).To(MatchJSON(`{
+			"code": 3,
+			"message": "invalid PingRequest.In: value must not be invalid",
+			"details": [{"@type": "type.googleapis.com/google.rpc.BadRequest", "field_violations": [{"field": "in", "description": "value must not be invalid"}]}]
+		}`))
+
+		res, err = http.Get(fmt.Sprintf("http://localhost:%d/ping?in=error", p.Addr().(*net.TCPAddr).Port))
+		Expect(err).NotTo(HaveOccurred())
+		res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusInternalServerError))
+	})
+})
 
 type TestService struct {
 }
 
+// Error formatted like the ones of protoc-gen-validate.
+type inError struct{}
+
+func (inError) Field() string  { return "in" }
+func (inError) Reason() string { return "value must not be invalid" }
+func (inError) Cause() error   { return nil }
+func (inError) Error() string  { return "invalid PingRequest.In: value must not be invalid" }
+
 func (s TestService) Ping(ctx context.Context, request *gen.PingRequest) (*gen.PingResponse, error) {
 	logger := ctxlogrus.Extract(ctx)
 	logger.Info("hello from ping")
@@ -157,5 +208,9 @@ func (s TestService) Ping(ctx context.Context, request *gen.PingRequest) (*gen.P
 		return nil, errors.New("please error me")
 	}
 
+	if request.In == "invalid" {
+		return nil, grpc.ValidationError(inError{})
+	}
+
 	return &gen.PingResponse{Out: request.In}, nil
 }
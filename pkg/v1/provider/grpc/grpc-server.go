@@ -1,9 +1,17 @@
 package grpc
 
 import (
+	"bytes"
 	"context"
-	"fmt"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
 	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/app"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/grpc/payload"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/listener"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/probes"
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	"github.com/grpc-ecosystem/go-grpc-middleware/auth"
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/logrus"
@@ -13,18 +21,22 @@ import (
 	"github.com/grpc-ecosystem/go-grpc-prometheus"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 	"net"
+	"sync"
 	"time"
 )
 
 // when create a grpc server, you can custom yourself interceptor
+// The Authenticator authenticates the requests (see Authenticator). With several of them, requests are accepted if any of them accepts them.
 type CustomOpts struct {
 	UnaryInterceptor  []grpc.UnaryServerInterceptor
 	StreamInterceptor []grpc.StreamServerInterceptor
 	ServerOption      []grpc.ServerOption
+	Authenticator     Authenticator
 }
 
 // GRPC Server Provider.
@@ -35,24 +47,69 @@ type Server struct {
 	provider.AbstractRunProvider
 
 	Config   *Config
-	Listener net.Listener
+	Listener net.Listener // Listener the GRPC Server is serving on, once running.
 	Server   *grpc.Server
 	Opts     []CustomOpts
+	Health   *health.Server // Health service reporting the status of the application and of each service, nil if disabled (see AddServiceProbes()).
+
+	tlsConfig      *tls.Config
+	authenticator  Authenticator
+	appProvider    *app.App
+	probesProvider *probes.Probes
+	injected       net.Listener
+	payloadLogger  *payload.Logger // Logs the payload if configured to do so, which can change while running (see Reconfigure()).
+	loaded         *Config         // Settings of the environment variables and configuration file when last loaded, so only changes to them are applied.
+	serviceProbes  map[string][]probes.ProbeFunc
+	healthStatus   map[string]grpc_health_v1.HealthCheckResponse_ServingStatus // Last status of each service, to log changes.
+	healthStop     chan struct{}
+	healthStopOnce sync.Once
 }
 
 // Creates a GRPC Server Provider.
 func New(config *Config, customOpts ...CustomOpts) *Server {
 	return &Server{
 		Config: config,
-		Opts: customOpts,
+		Opts:   customOpts,
 	}
 }
 
+// Returns the invalid settings of the GRPC Server configuration, if any (see provider.Configured).
+func (p *Server) ConfigError() error {
+	return config.Err(p.Config)
+}
+
+// The GRPC Server depends on the (optional) App and Probes Providers.
+func (p *Server) Dependencies() []provider.Provider {
+	return []provider.Provider{p.appProvider, p.probesProvider}
+}
+
+// Looks up the optional App Provider in the Stack, used to publish the build metadata (see BuildInfoMethod),
+// and the optional Probes Provider, whose probes determine the health status.
+func (p *Server) Resolve(registry provider.Registry) error {
+	if p.appProvider == nil {
+		registry.Lookup(&p.appProvider)
+	}
+	if p.probesProvider == nil {
+		registry.Lookup(&p.probesProvider)
+	}
+	return nil
+}
+
 // Creates the GRPC Server (doesn't start it yet) and adds useful interceptors.
+// Requests are authenticated by the Authenticators of the custom options, except for the methods of the skip list (see AUTH_SKIP_METHODS).
+// Rejects invalid requests with the InvalidArgument code if configured to validate them (see ValidationError()).
+// Serves TLS if configured, adding the verified identity of clients presenting a certificate to the request context (see PeerIdentityFromContext()).
+// Registers the health endpoint, and the build info service if an App Provider is in the Stack.
 func (p *Server) Init() error {
 	logger := logrus.NewEntry(logrus.StandardLogger())
 
-	grpc_logrus.JsonPbMarshaller = NewJsonPbMarshaller()
+	tlsConfig, err := p.Config.TLS.ServerTLSConfig()
+	if err != nil {
+		logrus.WithError(err).Error("GRPC Server TLS configuration failed")
+		return err
+	}
+	p.tlsConfig = tlsConfig
+
 	opts := []grpc_logrus.Option{
 		grpc_logrus.WithDurationField(func(duration time.Duration) (key string, value interface{}) {
 			return "grpc.time_ns", duration.Nanoseconds()
@@ -60,66 +117,105 @@ func (p *Server) Init() error {
 	}
 
 	// Unary and streaming have the same interceptors.
+	// Recovery comes first, so panics of the other interceptors (e.g. of an Authenticator) are returned as Internal errors as well.
 	unaryInterceptors := []grpc.UnaryServerInterceptor{
+		grpc_recovery.UnaryServerInterceptor(),
 		grpc_ctxtags.UnaryServerInterceptor(),
+		peerIdentityUnaryServerInterceptor,
 		grpc_opentracing.UnaryServerInterceptor(),
 		grpc_prometheus.UnaryServerInterceptor,
 		grpc_logrus.UnaryServerInterceptor(logger, opts...),
 		grpc_auth.UnaryServerInterceptor(p.authFunc),
-		grpc_recovery.UnaryServerInterceptor(),
 	}
 	streamInterceptors := []grpc.StreamServerInterceptor{
+		grpc_recovery.StreamServerInterceptor(),
 		grpc_ctxtags.StreamServerInterceptor(),
+		peerIdentityStreamServerInterceptor,
 		grpc_opentracing.StreamServerInterceptor(),
 		grpc_prometheus.StreamServerInterceptor,
 		grpc_logrus.StreamServerInterceptor(logger, opts...),
 		grpc_auth.StreamServerInterceptor(p.authFunc),
-		grpc_recovery.StreamServerInterceptor(),
 	}
 
-	// Payload is only logged by the Server if it was configured to do so.
-	if p.Config.LogPayload {
-		unaryInterceptors = append(unaryInterceptors, grpc_logrus.PayloadUnaryServerInterceptor(logger, p.logDeciderFunc))
-		streamInterceptors = append(streamInterceptors, grpc_logrus.PayloadStreamServerInterceptor(logger, p.logDeciderFunc))
+	// Payload is only logged by the Server if it was configured to do so, following the payload logging policy.
+	// The interceptors are always added, so payload logging can be enabled while running.
+	p.payloadLogger = payload.NewLogger(logger, p.Config.Payload)
+	p.setLogPayload(p.Config.LogPayload)
+	p.loaded = &Config{Payload: &payload.Policy{}}
+	_ = config.Reload("GRPC", p.loaded)
+	_ = config.Reload("GRPC_PAYLOAD_LOG", p.loaded.Payload)
+	unaryInterceptors = append(unaryInterceptors, p.payloadLogger.UnaryServerInterceptor())
+	streamInterceptors = append(streamInterceptors, p.payloadLogger.StreamServerInterceptor())
+
+	// Requests implementing Validator or AllValidator (e.g. generated by protoc-gen-validate) are validated before the custom interceptors and handlers.
+	if p.Config.ValidateRequests {
+		unaryInterceptors = append(unaryInterceptors, validationUnaryServerInterceptor)
+		streamInterceptors = append(streamInterceptors, validationStreamServerInterceptor)
 	}
 
-	var serverOpts []grpc.ServerOption
-	for _, opt := range p.Opts{
+	// Custom server options come after the transport options, so they take precedence.
+	serverOpts := p.Config.transportOptions()
+	if p.tlsConfig != nil {
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(p.tlsConfig)))
+	}
+	var authenticators anyAuthenticator
+	for _, opt := range p.Opts {
 		unaryInterceptors = append(unaryInterceptors, opt.UnaryInterceptor...)
 		streamInterceptors = append(streamInterceptors, opt.StreamInterceptor...)
 		serverOpts = append(serverOpts, opt.ServerOption...)
+		if opt.Authenticator != nil {
+			authenticators = append(authenticators, opt.Authenticator)
+		}
+	}
+	switch len(authenticators) {
+	case 0:
+		logrus.Debug("GRPC Server authentication disabled, no Authenticator configured")
+	case 1:
+		p.authenticator = authenticators[0]
+	default:
+		p.authenticator = authenticators
 	}
 
 	serverOpts = append(
 		serverOpts,
 		grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(unaryInterceptors...)),
 		grpc.StreamInterceptor(grpc_middleware.ChainStreamServer(streamInterceptors...)),
-		)
+	)
 
 	p.Server = grpc.NewServer(serverOpts...)
+	p.registerHealthEndpoint()
+	p.registerBuildInfoService()
 
 	return nil
 }
 
 // Creates a GRPC Listener on the configured port which is used to start the GRPC Server.
+// Port 0 results in an ephemeral port (see Addr()). The port isn't bound if a listener was set, or a systemd socket was configured and passed.
 // Uses the GRPC Server reflection functionality find the available handlers.
 func (p *Server) Run() error {
-	addr := fmt.Sprintf(":%d", p.Config.Port)
-	logEntry := logrus.WithField("addr", addr)
+	logEntry := logrus.WithField("port", p.Config.Port)
 
 	reflection.Register(p.Server)
 
-	listener, err := net.Listen("tcp", addr)
+	l, err := listener.Listen(p.Config.Port, p.Config.SocketName, p.injected)
 	if err != nil {
 		logEntry.WithError(err).Error("GRPC Server Listener could not be created")
 		return err
 	}
-	p.Listener = listener
+	p.Listener = l
+
+	// Services are registered by now, so they all get a health status before the first request.
+	if p.Health != nil {
+		p.checkHealth()
+		if p.Config.HealthCheckInterval > 0 {
+			go p.watchHealth()
+		}
+	}
 	p.SetRunning(true)
-	p.registerHealthEndpoint()
 
+	logEntry = logEntry.WithFields(logrus.Fields{"addr": l.Addr().String(), "tls": p.tlsConfig != nil})
 	logEntry.Info("GRPC Server Provider launched")
-	if err := p.Server.Serve(listener); err != nil {
+	if err := p.Server.Serve(l); err != nil {
 		logEntry.WithError(err).Error("GRPC Server Provider launch failed")
 		return err
 	}
@@ -127,29 +223,112 @@ func (p *Server) Run() error {
 	return nil
 }
 
-// Shuts down the GRPC Server.
+// Makes the GRPC Server serve on the given listener instead of binding its configured port (e.g. a listener opened by a test or sidecar).
+// Should be called before the GRPC Server runs.
+func (p *Server) SetListener(listener net.Listener) {
+	p.injected = listener
+}
+
+// Returns the address the GRPC Server is listening on once it is running (e.g. to discover an ephemeral port), or nil otherwise.
+func (p *Server) Addr() net.Addr {
+	if !p.IsRunning() {
+		return nil
+	}
+	return p.Listener.Addr()
+}
+
+// Returns the option to dial the GRPC Server from the same process (e.g. the GRPC Gateway Provider), once initialized.
+// Plaintext is used unless TLS is enabled. With TLS, the server is trusted if it presents its own current certificate,
+// which is also presented as client certificate, so it has to be signed by a client CA when those are configured (mTLS).
+func (p *Server) LoopbackDialOption() grpc.DialOption {
+	if p.tlsConfig == nil {
+		return grpc.WithInsecure()
+	}
+	current := func() (*tls.Certificate, error) {
+		return p.tlsConfig.GetCertificate(&tls.ClientHelloInfo{})
+	}
+	return grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// The address of the server usually isn't in its certificate, so the certificate is compared instead of verified.
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			cert, err := current()
+			if err != nil {
+				return err
+			}
+			if len(rawCerts) == 0 || !bytes.Equal(rawCerts[0], cert.Certificate[0]) {
+				return errors.New("GRPC Server presented an unexpected certificate")
+			}
+			return nil
+		},
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return current()
+		},
+	}))
+}
+
+// Shuts down the GRPC Server, waiting for pending RPCs to finish.
 func (p *Server) Close() error {
-	p.Server.GracefulStop()
+	return p.CloseContext(context.Background())
+}
+
+// Gracefully shuts down the GRPC Server: pending RPCs are completed until the context is done, after which the server is stopped forcefully.
+func (p *Server) CloseContext(ctx context.Context) error {
+	p.stopHealth()
+
+	stopped := make(chan struct{})
+	go func() {
+		p.Server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		logrus.WithError(ctx.Err()).Warn("GRPC Server did not stop gracefully in time, stopping forcefully")
+		p.Server.Stop()
+	}
 
 	return p.AbstractRunProvider.Close()
 }
 
-func (p *Server) authFunc(ctx context.Context) (context.Context, error) {
-	// TODO: Add support for authentication.
-	return ctx, nil
+// Sets the health status of all services to NOT_SERVING as soon as the shutdown sequence starts, so clients stop sending traffic.
+func (p *Server) OnShutdown(phase provider.ShutdownPhase) {
+	if phase == provider.ShutdownPhaseDrain && p.Health != nil {
+		p.Health.Shutdown()
+		logrus.Info("GRPC Server health status set to NOT_SERVING due to shutdown")
+	}
 }
 
-func (p *Server) logDeciderFunc(ctx context.Context, fullMethodName string, servingObject interface{}) bool {
-	// TODO: Should we really log everything?
-	return true
-}
+// Applies a changed LogPayload setting and payload logging policy. Other settings only apply after a restart.
+// Settings are only replaced when the environment variables or the configuration file changed them, so settings made in code are kept otherwise.
+func (p *Server) Reconfigure() error {
+	c := &Config{}
+	if err := config.Reload("GRPC", c); err != nil {
+		return err
+	}
+	policy, err := payload.ReloadPolicy()
+	if err != nil {
+		return err
+	}
 
-func (p *Server) registerHealthEndpoint() {
-	if !p.Config.EnableHealth {
-		logrus.Debug("GRPC Server health endpoint disabled")
-		return
+	if c.LogPayload != p.loaded.LogPayload {
+		logrus.WithField("log_payload", c.LogPayload).Info("GRPC Server payload logging changed")
+		p.setLogPayload(c.LogPayload)
 	}
-	healthServer := health.NewServer()
-	grpc_health_v1.RegisterHealthServer(p.Server, healthServer)
-	logrus.Debug("GRPC Server health endpoint registered")
+	current := *p.payloadLogger.Policy()
+	if changed := config.ApplyChanges(&current, p.loaded.Payload, policy); len(changed) > 0 {
+		logrus.WithFields(config.Fields(&current)).WithField("changed", changed).Info("GRPC Server payload logging policy changed")
+		p.payloadLogger.SetPolicy(&current)
+	}
+	c.Payload, p.loaded = policy, c
+	return nil
+}
+
+func (p *Server) setLogPayload(logPayload bool) {
+	p.payloadLogger.SetEnabled(logPayload)
+}
+
+func (p *Server) isLoggingPayload() bool {
+	return p.payloadLogger.Enabled()
 }
@@ -2,15 +2,44 @@ package grpc
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"errors"
+	"fmt"
 	"github.azc.ext.hp.com/hp-business-platform/lib-core-go/pkg/v1/test"
 	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/examples/ping/server/gen"
 	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/app"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/grpc/payload"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/probes"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/tlsconfig"
+	"github.com/golang/protobuf/ptypes/empty"
+	structpb "github.com/golang/protobuf/ptypes/struct"
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/logrus/ctxlogrus"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -27,7 +56,7 @@ var _ = Describe("GRPC server provider test", func() {
 
 		By("Creating and initializing the provider", func() {
 			p = New(&Config{
-				Port:         defaultPort,
+				Port:         0,
 				LogPayload:   true,
 				EnableHealth: true,
 			})
@@ -42,12 +71,14 @@ var _ = Describe("GRPC server provider test", func() {
 				err := p.Run()
 				Expect(err).NotTo(HaveOccurred())
 			}()
-			err := provider.WaitForRunningProvider(p, 2*time.Second)
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			err := provider.WaitReady(ctx, p)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(p.IsRunning()).To(BeTrue())
 		})
 		By("Dialing into the grpc service", func() {
-			conn, err := grpc.Dial("127.0.0.1:3000", grpc.WithInsecure())
+			conn, err := grpc.Dial(fmt.Sprintf("127.0.0.1:%d", p.Addr().(*net.TCPAddr).Port), grpc.WithInsecure())
 			Expect(err).NotTo(HaveOccurred())
 			Expect(conn).NotTo(BeNil())
 
@@ -60,11 +91,326 @@ var _ = Describe("GRPC server provider test", func() {
 			err = conn.Invoke(context.Background(), "/api.PingService/Ping", &request, &response)
 			Expect(err).NotTo(HaveOccurred())
 		})
+		By("Keeping the payload logging set in code until the environment changes it", func() {
+			Expect(p.Reconfigure()).To(Succeed())
+			Expect(p.isLoggingPayload()).To(BeTrue())
+		})
+		By("Changing the payload logging policy while running", func() {
+			_ = os.Setenv("GRPC_PAYLOAD_LOG_MAX_SIZE", "10")
+			defer os.Unsetenv("GRPC_PAYLOAD_LOG_MAX_SIZE")
+			Expect(p.Reconfigure()).To(Succeed())
+			Expect(p.payloadLogger.Policy().MaxSize).To(Equal(10))
+			Expect(p.payloadLogger.Policy().Exclude).To(Equal(payload.DefaultPolicy().Exclude))
+		})
+		By("Disabling payload logging while running", func() {
+			Expect(p.isLoggingPayload()).To(BeTrue())
+			_ = os.Setenv("GRPC_LOG_PAYLOAD", "true")
+			defer os.Unsetenv("GRPC_LOG_PAYLOAD")
+			Expect(p.Reconfigure()).To(Succeed())
+			Expect(p.isLoggingPayload()).To(BeTrue())
+			_ = os.Setenv("GRPC_LOG_PAYLOAD", "false")
+			Expect(p.Reconfigure()).To(Succeed())
+			Expect(p.isLoggingPayload()).To(BeFalse())
+
+			_ = os.Setenv("GRPC_LOG_PAYLOAD", "sometimes")
+			Expect(p.Reconfigure()).ToNot(Succeed())
+			Expect(p.isLoggingPayload()).To(BeFalse())
+		})
+		By("Reporting NOT_SERVING once the shutdown sequence starts", func() {
+			conn, err := grpc.Dial(fmt.Sprintf("127.0.0.1:%d", p.Addr().(*net.TCPAddr).Port), grpc.WithInsecure())
+			Expect(err).NotTo(HaveOccurred())
+			client := grpc_health_v1.NewHealthClient(conn)
+
+			res, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Status).To(Equal(grpc_health_v1.HealthCheckResponse_SERVING))
+
+			p.OnShutdown(provider.ShutdownPhaseDrain)
+			res, err = client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Status).To(Equal(grpc_health_v1.HealthCheckResponse_NOT_SERVING))
+			res, err = client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "api.PingService"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Status).To(Equal(grpc_health_v1.HealthCheckResponse_NOT_SERVING))
+		})
 		By("Shutting down the server", func() {
 			err := p.Close()
 			Expect(err).ToNot(HaveOccurred())
 		})
 	})
+	It("Publishes the build metadata of the App Provider", func() {
+		p := New(&Config{Port: 0})
+		p.appProvider = app.New(&app.Config{Name: "grpc-test", BasePath: "/"})
+		Expect(p.Init()).To(Succeed())
+		go func() {
+			_ = p.Run()
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		Expect(provider.WaitReady(ctx, p)).To(Succeed())
+		defer func() {
+			Expect(p.Close()).To(Succeed())
+		}()
+
+		conn, err := grpc.Dial(fmt.Sprintf("127.0.0.1:%d", p.Addr().(*net.TCPAddr).Port), grpc.WithInsecure())
+		Expect(err).NotTo(HaveOccurred())
+		defer conn.Close()
+
+		info := &structpb.Struct{}
+		Expect(conn.Invoke(context.Background(), BuildInfoMethod, &empty.Empty{}, info)).To(Succeed())
+		Expect(info.Fields["name"].GetStringValue()).To(Equal("grpc-test"))
+		Expect(info.Fields["go_version"].GetStringValue()).To(Equal(runtime.Version()))
+	})
+	It("Authenticates requests", func() {
+		authenticator := AuthenticatorFunc(func(ctx context.Context) (context.Context, error) {
+			if md, _ := metadata.FromIncomingContext(ctx); len(md.Get("token")) == 0 || md.Get("token")[0] != "secret" {
+				return nil, errors.New("invalid token")
+			}
+			return ctx, nil
+		})
+		p := New(&Config{Port: 0, EnableHealth: true, AuthSkipMethods: []string{"/grpc.health.v1.Health/"}}, CustomOpts{Authenticator: authenticator})
+		Expect(p.Init()).To(Succeed())
+		gen.RegisterPingServiceServer(p.Server, TestService{})
+		go func() {
+			_ = p.Run()
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		Expect(provider.WaitReady(ctx, p)).To(Succeed())
+		defer func() {
+			Expect(p.Close()).To(Succeed())
+		}()
+
+		conn, err := grpc.Dial(fmt.Sprintf("127.0.0.1:%d", p.Addr().(*net.TCPAddr).Port), grpc.WithInsecure())
+		Expect(err).NotTo(HaveOccurred())
+		defer conn.Close()
+		ping := func(token string) error {
+			ctx := metadata.AppendToOutgoingContext(context.Background(), "token", token)
+			return conn.Invoke(ctx, "/api.PingService/Ping", &gen.PingRequest{In: "Hello"}, &gen.PingResponse{})
+		}
+
+		Expect(status.Code(ping("guess"))).To(Equal(codes.Unauthenticated))
+		Expect(ping("secret")).To(Succeed())
+		_, err = grpc_health_v1.NewHealthClient(conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		Expect(err).ToNot(HaveOccurred())
+	})
+	It("Recovers from panicking authenticators", func() {
+		p := New(&Config{Port: 0}, CustomOpts{Authenticator: AuthenticatorFunc(func(ctx context.Context) (context.Context, error) {
+			panic("authenticator panicked")
+		})})
+		Expect(p.Init()).To(Succeed())
+		gen.RegisterPingServiceServer(p.Server, TestService{})
+		go func() {
+			_ = p.Run()
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		Expect(provider.WaitReady(ctx, p)).To(Succeed())
+		defer func() {
+			Expect(p.Close()).To(Succeed())
+		}()
+
+		conn, err := grpc.Dial(fmt.Sprintf("127.0.0.1:%d", p.Addr().(*net.TCPAddr).Port), grpc.WithInsecure())
+		Expect(err).NotTo(HaveOccurred())
+		defer conn.Close()
+		err = conn.Invoke(context.Background(), "/api.PingService/Ping", &gen.PingRequest{In: "Hello"}, &gen.PingResponse{})
+		Expect(status.Code(err)).To(Equal(codes.Internal))
+	})
+	It("Lets services authenticate their requests themselves", func() {
+		p := New(&Config{Port: 0}, CustomOpts{Authenticator: AuthenticatorFunc(func(ctx context.Context) (context.Context, error) {
+			return nil, errors.New("rejected")
+		})})
+		Expect(p.Init()).To(Succeed())
+		gen.RegisterPingServiceServer(p.Server, PublicTestService{})
+		go func() {
+			_ = p.Run()
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		Expect(provider.WaitReady(ctx, p)).To(Succeed())
+		defer func() {
+			Expect(p.Close()).To(Succeed())
+		}()
+
+		conn, err := grpc.Dial(fmt.Sprintf("127.0.0.1:%d", p.Addr().(*net.TCPAddr).Port), grpc.WithInsecure())
+		Expect(err).NotTo(HaveOccurred())
+		defer conn.Close()
+		Expect(conn.Invoke(context.Background(), "/api.PingService/Ping", &gen.PingRequest{In: "Hello"}, &gen.PingResponse{})).To(Succeed())
+	})
+	It("Reports the health status from the probes, per service", func() {
+		var ready, pingReady int32 = 1, 1
+		probesProvider := probes.New(&probes.Config{}, nil)
+		probesProvider.AddReadinessProbes(func() error {
+			if atomic.LoadInt32(&ready) == 0 {
+				return errors.New("database unreachable")
+			}
+			return nil
+		})
+		p := New(&Config{Port: 0, EnableHealth: true, HealthCheckInterval: 10 * time.Millisecond})
+		p.probesProvider = probesProvider
+		p.AddServiceProbes("api.PingService", func() error {
+			if atomic.LoadInt32(&pingReady) == 0 {
+				return errors.New("upstream unreachable")
+			}
+			return nil
+		})
+		Expect(p.Init()).To(Succeed())
+		gen.RegisterPingServiceServer(p.Server, TestService{})
+		go func() {
+			_ = p.Run()
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		Expect(provider.WaitReady(ctx, p)).To(Succeed())
+		defer func() {
+			Expect(p.Close()).To(Succeed())
+		}()
+
+		conn, err := grpc.Dial(fmt.Sprintf("127.0.0.1:%d", p.Addr().(*net.TCPAddr).Port), grpc.WithInsecure())
+		Expect(err).NotTo(HaveOccurred())
+		defer conn.Close()
+		client := grpc_health_v1.NewHealthClient(conn)
+		check := func(service string) func() grpc_health_v1.HealthCheckResponse_ServingStatus {
+			return func() grpc_health_v1.HealthCheckResponse_ServingStatus {
+				res, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: service})
+				Expect(err).NotTo(HaveOccurred())
+				return res.Status
+			}
+		}
+		Expect(check("")()).To(Equal(grpc_health_v1.HealthCheckResponse_SERVING))
+		Expect(check("api.PingService")()).To(Equal(grpc_health_v1.HealthCheckResponse_SERVING))
+
+		watchCtx, stopWatching := context.WithCancel(context.Background())
+		defer stopWatching()
+		watch, err := client.Watch(watchCtx, &grpc_health_v1.HealthCheckRequest{Service: "api.PingService"})
+		Expect(err).NotTo(HaveOccurred())
+		res, err := watch.Recv()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Status).To(Equal(grpc_health_v1.HealthCheckResponse_SERVING))
+
+		atomic.StoreInt32(&pingReady, 0)
+		res, err = watch.Recv()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Status).To(Equal(grpc_health_v1.HealthCheckResponse_NOT_SERVING))
+		Expect(check("")()).To(Equal(grpc_health_v1.HealthCheckResponse_SERVING))
+
+		atomic.StoreInt32(&pingReady, 1)
+		atomic.StoreInt32(&ready, 0)
+		Eventually(check("")).Should(Equal(grpc_health_v1.HealthCheckResponse_NOT_SERVING))
+		Expect(check("grpc.health.v1.Health")()).To(Equal(grpc_health_v1.HealthCheckResponse_NOT_SERVING))
+		Expect(check("api.PingService")()).To(Equal(grpc_health_v1.HealthCheckResponse_NOT_SERVING))
+
+		atomic.StoreInt32(&ready, 1)
+		Eventually(check("api.PingService")).Should(Equal(grpc_health_v1.HealthCheckResponse_SERVING))
+	})
+	It("Rejects invalid requests, with their field violations", func() {
+		invalid := &validatedRequest{PingRequest: &gen.PingRequest{}, err: testMultiError{
+			testValidationError{field: "in", reason: "value length must be at least 1 runes"},
+			testValidationError{field: "address", reason: "embedded message failed validation", cause: testValidationError{field: "zip_code", reason: "value does not match regex pattern"}},
+		}}
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			return &gen.PingResponse{}, nil
+		}
+
+		_, err := validationUnaryServerInterceptor(context.Background(), invalid, &grpc.UnaryServerInfo{FullMethod: "/api.PingService/Ping"}, handler)
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+		details := status.Convert(err).Details()
+		Expect(details).To(HaveLen(1))
+		Expect(details[0].(*errdetails.BadRequest).FieldViolations).To(Equal([]*errdetails.BadRequest_FieldViolation{
+			{Field: "in", Description: "value length must be at least 1 runes"},
+			{Field: "address.zip_code", Description: "value does not match regex pattern"},
+		}))
+
+		_, err = validationUnaryServerInterceptor(context.Background(), &validatedRequest{PingRequest: &gen.PingRequest{In: "Hello"}}, &grpc.UnaryServerInfo{FullMethod: "/api.PingService/Ping"}, handler)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = validationUnaryServerInterceptor(context.Background(), &gen.PingRequest{}, &grpc.UnaryServerInfo{FullMethod: "/api.PingService/Ping"}, handler)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = validationStreamServerInterceptor(nil, &recvServerStream{}, &grpc.StreamServerInfo{FullMethod: "/api.PingService/PingStream"}, func(srv interface{}, stream grpc.ServerStream) error {
+			return stream.RecvMsg(invalid)
+		})
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+	})
+	It("Limits the size of received messages", func() {
+		p := New(&Config{Port: 0, MaxRecvMsgSize: 64, KeepalivePermitWithoutStream: true})
+		Expect(p.Init()).To(Succeed())
+		gen.RegisterPingServiceServer(p.Server, TestService{})
+		go func() {
+			_ = p.Run()
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		Expect(provider.WaitReady(ctx, p)).To(Succeed())
+		defer func() {
+			Expect(p.Close()).To(Succeed())
+		}()
+
+		conn, err := grpc.Dial(fmt.Sprintf("127.0.0.1:%d", p.Addr().(*net.TCPAddr).Port), grpc.WithInsecure())
+		Expect(err).NotTo(HaveOccurred())
+		defer conn.Close()
+		Expect(conn.Invoke(context.Background(), "/api.PingService/Ping", &gen.PingRequest{In: "Hello"}, &gen.PingResponse{})).To(Succeed())
+		err = conn.Invoke(context.Background(), "/api.PingService/Ping", &gen.PingRequest{In: strings.Repeat("Hello", 20)}, &gen.PingResponse{})
+		Expect(status.Code(err)).To(Equal(codes.ResourceExhausted))
+	})
+	It("Serves TLS, verifying client certificates", func() {
+		dir, err := ioutil.TempDir("", "grpc-tls")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+
+		ca := newCA()
+		caFile := filepath.Join(dir, "ca.pem")
+		Expect(ioutil.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0600)).To(Succeed())
+		certFile, keyFile := writeCert(dir, "server", "server", ca)
+		clientCertFile, clientKeyFile := writeCert(dir, "client", "client", ca)
+
+		p := New(&Config{Port: 0, TLS: &tlsconfig.Config{Enabled: true, CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile}})
+		Expect(p.Init()).To(Succeed())
+		gen.RegisterPingServiceServer(p.Server, TestService{})
+		go func() {
+			_ = p.Run()
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		Expect(provider.WaitReady(ctx, p)).To(Succeed())
+		defer func() {
+			Expect(p.Close()).To(Succeed())
+		}()
+		addr := fmt.Sprintf("localhost:%d", p.Addr().(*net.TCPAddr).Port)
+		ping := func(opt grpc.DialOption) (string, error) {
+			conn, err := grpc.Dial(addr, opt)
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			response := &gen.PingResponse{}
+			err = conn.Invoke(ctx, "/api.PingService/Ping", &gen.PingRequest{In: "peer"}, response)
+			return response.Out, err
+		}
+
+		By("Rejecting plaintext clients and clients without a certificate", func() {
+			_, err := ping(grpc.WithInsecure())
+			Expect(err).To(HaveOccurred())
+			pool := x509.NewCertPool()
+			pool.AddCert(ca.cert)
+			_, err = ping(grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{RootCAs: pool})))
+			Expect(err).To(HaveOccurred())
+		})
+		By("Exposing the identity of clients with a verified certificate", func() {
+			clientCert, err := tls.LoadX509KeyPair(clientCertFile, clientKeyFile)
+			Expect(err).ToNot(HaveOccurred())
+			pool := x509.NewCertPool()
+			pool.AddCert(ca.cert)
+			out, err := ping(grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{RootCAs: pool, Certificates: []tls.Certificate{clientCert}})))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal("client spiffe://test/client"))
+		})
+		By("Dialing from the same process", func() {
+			out, err := ping(p.LoopbackDialOption())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal("server spiffe://test/server"))
+		})
+	})
 })
 
 type TestService struct {
@@ -82,5 +428,116 @@ func (s TestService) Ping(ctx context.Context, request *gen.PingRequest) (*gen.P
 		return nil, errors.New("please error me")
 	}
 
+	if request.In == "peer" {
+		identity, ok := PeerIdentityFromContext(ctx)
+		if !ok {
+			return nil, errors.New("no peer identity")
+		}
+		return &gen.PingResponse{Out: identity.CommonName + " " + strings.Join(identity.URIs, " ")}, nil
+	}
+
 	return &gen.PingResponse{Out: request.In}, nil
 }
+
+// Ping service accepting every request, whatever the Authenticator of the server.
+type PublicTestService struct {
+	TestService
+}
+
+func (s PublicTestService) AuthFuncOverride(ctx context.Context, fullMethodName string) (context.Context, error) {
+	return ctx, nil
+}
+
+// Request validating itself like the messages generated by protoc-gen-validate.
+type validatedRequest struct {
+	*gen.PingRequest
+	err error
+}
+
+func (r *validatedRequest) ValidateAll() error {
+	return r.err
+}
+
+// Errors formatted like the ones of protoc-gen-validate.
+type testValidationError struct {
+	field  string
+	reason string
+	cause  error
+}
+
+func (e testValidationError) Field() string  { return e.field }
+func (e testValidationError) Reason() string { return e.reason }
+func (e testValidationError) Cause() error   { return e.cause }
+func (e testValidationError) Error() string  { return "invalid " + e.field + ": " + e.reason }
+
+type testMultiError []error
+
+func (m testMultiError) AllErrors() []error { return m }
+func (m testMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Server stream receiving empty messages.
+type recvServerStream struct {
+	grpc.ServerStream
+}
+
+func (s *recvServerStream) RecvMsg(m interface{}) error {
+	return nil
+}
+
+type signer struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// Creates a self-signed CA.
+func newCA() *signer {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).ToNot(HaveOccurred())
+	cert, err := x509.ParseCertificate(der)
+	Expect(err).ToNot(HaveOccurred())
+	return &signer{cert: cert, key: key}
+}
+
+// Writes a certificate and key with the given common name (and SPIFFE ID), signed by the CA.
+func writeCert(dir, name, commonName string, ca *signer) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+	spiffeID, err := url.Parse("spiffe://test/" + commonName)
+	Expect(err).ToNot(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{"localhost"},
+		URIs:         []*url.URL{spiffeID},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	Expect(err).ToNot(HaveOccurred())
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	Expect(err).ToNot(HaveOccurred())
+
+	certFile, keyFile := filepath.Join(dir, name+".pem"), filepath.Join(dir, name+"-key.pem")
+	Expect(ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)).To(Succeed())
+	Expect(ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)).To(Succeed())
+	return certFile, keyFile
+}
@@ -0,0 +1,100 @@
+package grpc
+
+import (
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/probes"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"time"
+)
+
+// Adds probes to the health status of a service (e.g. "api.PingService"), on top of the probes of the Probes Provider.
+// The service reports NOT_SERVING while any of its probes fails. Should be called before the GRPC Server runs.
+func (p *Server) AddServiceProbes(service string, fn probes.ProbeFunc) {
+	if p.serviceProbes == nil {
+		p.serviceProbes = map[string][]probes.ProbeFunc{}
+	}
+	p.serviceProbes[service] = append(p.serviceProbes[service], fn)
+}
+
+func (p *Server) registerHealthEndpoint() {
+	if !p.Config.EnableHealth {
+		logrus.Debug("GRPC Server health endpoint disabled")
+		return
+	}
+	p.Health = health.NewServer()
+	p.healthStatus = map[string]grpc_health_v1.HealthCheckResponse_ServingStatus{}
+	p.healthStop = make(chan struct{})
+	grpc_health_v1.RegisterHealthServer(p.Server, p.Health)
+	logrus.Debug("GRPC Server health endpoint registered")
+}
+
+// Checks the health of the application and of the registered services every HEALTH_CHECK_INTERVAL, until stopped.
+func (p *Server) watchHealth() {
+	ticker := time.NewTicker(p.Config.HealthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.checkHealth()
+		case <-p.healthStop:
+			return
+		}
+	}
+}
+
+func (p *Server) stopHealth() {
+	if p.healthStop != nil {
+		p.healthStopOnce.Do(func() {
+			close(p.healthStop)
+		})
+	}
+}
+
+// Sets the status of the server (the empty service name) according to the liveness and readiness probes of the Probes Provider,
+// and the status of each registered service according to the status of the server and its own probes.
+func (p *Server) checkHealth() {
+	var err error
+	if p.probesProvider != nil {
+		if err = p.probesProvider.Live(); err == nil {
+			err = p.probesProvider.Ready()
+		}
+	}
+	p.setServingStatus("", err)
+
+	services := map[string]bool{}
+	for service := range p.Server.GetServiceInfo() {
+		services[service] = true
+	}
+	for service := range p.serviceProbes {
+		services[service] = true
+	}
+	for service := range services {
+		serviceErr := err
+		for _, probe := range p.serviceProbes[service] {
+			if serviceErr != nil {
+				break
+			}
+			serviceErr = probe()
+		}
+		p.setServingStatus(service, serviceErr)
+	}
+}
+
+// Sets the status of the service, logging changes.
+func (p *Server) setServingStatus(service string, err error) {
+	status := grpc_health_v1.HealthCheckResponse_SERVING
+	if err != nil {
+		status = grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	}
+	if previous, ok := p.healthStatus[service]; !ok || previous != status {
+		logEntry := logrus.WithFields(logrus.Fields{"service": service, "status": status.String()})
+		if err != nil {
+			logEntry.WithError(err).Warn("GRPC Server health status changed")
+		} else if ok {
+			logEntry.Info("GRPC Server health status changed")
+		}
+		p.healthStatus[service] = status
+	}
+	p.Health.SetServingStatus(service, status)
+}
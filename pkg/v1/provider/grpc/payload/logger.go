@@ -0,0 +1,214 @@
+package payload
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/logrus/ctxlogrus"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"path"
+	"sync/atomic"
+)
+
+// Logs the payloads of GRPC calls as the grpc.request.content and grpc.response.content fields, following a Policy.
+// Logging can be enabled and the policy replaced while running.
+type Logger struct {
+	entry   *logrus.Entry
+	enabled int32
+	policy  atomic.Value // *Policy
+}
+
+// Creates a payload Logger, enabled and using the given policy (or the default policy if nil).
+func NewLogger(entry *logrus.Entry, policy *Policy) *Logger {
+	l := &Logger{entry: entry, enabled: 1}
+	l.SetPolicy(policy)
+	return l
+}
+
+// Enables or disables logging.
+func (l *Logger) SetEnabled(enabled bool) {
+	var value int32
+	if enabled {
+		value = 1
+	}
+	atomic.StoreInt32(&l.enabled, value)
+}
+
+// Whether or not payloads are logged.
+func (l *Logger) Enabled() bool {
+	return atomic.LoadInt32(&l.enabled) == 1
+}
+
+// Replaces the policy, the default policy is used if nil.
+func (l *Logger) SetPolicy(policy *Policy) {
+	if policy == nil {
+		policy = DefaultPolicy()
+	}
+	l.policy.Store(policy)
+}
+
+// Returns the current policy.
+func (l *Logger) Policy() *Policy {
+	return l.policy.Load().(*Policy)
+}
+
+// Returns the policy if the payload of a call to the method is logged, or nil otherwise.
+func (l *Logger) decide(fullMethodName string) *Policy {
+	if !l.Enabled() {
+		return nil
+	}
+	if policy := l.Policy(); policy.Decide(fullMethodName) {
+		return policy
+	}
+	return nil
+}
+
+// Logs the payloads of unary calls to a GRPC Server. Should be placed after the logging interceptor, as it logs the same fields.
+func (l *Logger) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		policy := l.decide(info.FullMethod)
+		if policy == nil {
+			return handler(ctx, req)
+		}
+		entry := l.entry.WithFields(ctxlogrus.Extract(ctx).Data)
+		logRequest(entry, policy, req, "server request payload logged as grpc.request.content field")
+		resp, err := handler(ctx, req)
+		if err == nil {
+			logResponse(entry, policy, resp, "server response payload logged as grpc.response.content field")
+		}
+		return resp, err
+	}
+}
+
+// Logs the payloads of streams of a GRPC Server. Should be placed after the logging interceptor, as it logs the same fields.
+func (l *Logger) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		policy := l.decide(info.FullMethod)
+		if policy == nil {
+			return handler(srv, stream)
+		}
+		entry := l.entry.WithFields(ctxlogrus.Extract(stream.Context()).Data)
+		return handler(srv, &serverStream{ServerStream: stream, entry: entry, policy: policy})
+	}
+}
+
+// Logs the payloads of unary calls of a GRPC client.
+func (l *Logger) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		policy := l.decide(method)
+		if policy == nil {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		entry := l.entry.WithFields(clientFields(method))
+		logRequest(entry, policy, req, "client request payload logged as grpc.request.content field")
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if err == nil {
+			logResponse(entry, policy, reply, "client response payload logged as grpc.response.content field")
+		}
+		return err
+	}
+}
+
+// Logs the payloads of streams of a GRPC client.
+func (l *Logger) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		policy := l.decide(method)
+		if policy == nil {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return &clientStream{ClientStream: stream, entry: l.entry.WithFields(clientFields(method)), policy: policy}, nil
+	}
+}
+
+// Same fields as the client logging interceptor.
+func clientFields(fullMethodName string) logrus.Fields {
+	return logrus.Fields{
+		"system":       "grpc",
+		"span.kind":    "client",
+		"grpc.service": path.Dir(fullMethodName)[1:],
+		"grpc.method":  path.Base(fullMethodName),
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	entry  *logrus.Entry
+	policy *Policy
+}
+
+func (s *serverStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		logResponse(s.entry, s.policy, m, "server response payload logged as grpc.response.content field")
+	}
+	return err
+}
+
+func (s *serverStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		logRequest(s.entry, s.policy, m, "server request payload logged as grpc.request.content field")
+	}
+	return err
+}
+
+type clientStream struct {
+	grpc.ClientStream
+	entry  *logrus.Entry
+	policy *Policy
+}
+
+func (s *clientStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	if err == nil {
+		logRequest(s.entry, s.policy, m, "client request payload logged as grpc.request.content field")
+	}
+	return err
+}
+
+func (s *clientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err == nil {
+		logResponse(s.entry, s.policy, m, "client response payload logged as grpc.response.content field")
+	}
+	return err
+}
+
+func logRequest(entry *logrus.Entry, policy *Policy, m interface{}, msg string) {
+	if pb, ok := m.(proto.Message); ok {
+		entry.WithField("grpc.request.content", &content{policy: policy, message: pb}).Info(msg)
+	}
+}
+
+func logResponse(entry *logrus.Entry, policy *Policy, m interface{}, msg string) {
+	if pb, ok := m.(proto.Message); ok {
+		entry.WithField("grpc.response.content", &content{policy: policy, message: pb}).Info(msg)
+	}
+}
+
+// Payload marshalled according to the policy once logged.
+type content struct {
+	policy  *Policy
+	message proto.Message
+}
+
+// Returns the redacted payload. Errors are returned as a JSON string, so the log entry can still be formatted.
+func (c *content) MarshalJSON() ([]byte, error) {
+	b, err := c.policy.Marshal(c.message)
+	if err != nil {
+		return json.Marshal(fmt.Sprintf("payload could not be marshalled: %s", err))
+	}
+	return b, nil
+}
+
+// Returns the redacted payload, for text formatters.
+func (c *content) String() string {
+	b, _ := c.MarshalJSON()
+	return string(b)
+}
@@ -0,0 +1,136 @@
+package payload
+
+import (
+	"context"
+	"encoding/json"
+	"github.azc.ext.hp.com/hp-business-platform/lib-core-go/pkg/v1/test"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/examples/ping/server/gen"
+	"github.com/golang/protobuf/proto"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"testing"
+)
+
+func TestPayload(t *testing.T) {
+	RegisterFailHandlerWithT(t, Fail)
+	RunSpecsWithDefaultAndCustomReporters(t, "GRPC payload logging test", test.LoadCustomReporters("../../test_provider_grpc_payload.xml"))
+}
+
+// Field option marking sensitive fields, like services would declare it with: extend google.protobuf.FieldOptions { bool sensitive = 50000; }
+var sensitiveOption = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptorpb.FieldOptions)(nil),
+	ExtensionType: (*bool)(nil),
+	Field:         50000,
+	Name:          "test.sensitive",
+	Tag:           "varint,50000,opt,name=sensitive",
+	Filename:      "test/options.proto",
+}
+
+var _ = Describe("GRPC payload logging", func() {
+	var policy *Policy
+	BeforeEach(func() {
+		policy = DefaultPolicy()
+	})
+
+	It("Decides which methods are logged", func() {
+		Expect(policy.Decide("/api.PingService/Ping")).To(BeTrue())
+		Expect(policy.Decide("/grpc.health.v1.Health/Check")).To(BeFalse())
+		Expect(policy.Decide("/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo")).To(BeFalse())
+
+		policy.Include = []string{"/api.PingService/*"}
+		Expect(policy.Decide("/api.PingService/Ping")).To(BeTrue())
+		Expect(policy.Decide("/api.UserService/Get")).To(BeFalse())
+
+		policy.SampleRate = 0
+		Expect(policy.Decide("/api.PingService/Ping")).To(BeFalse())
+	})
+	It("Rejects invalid method patterns", func() {
+		policy.Exclude = []string{"/api.PingService/["}
+		Expect(policy.Validate()).ToNot(Succeed())
+	})
+	It("Redacts fields by name", func() {
+		b, err := policy.Marshal(&gen.PingRequest{In: "Hello"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(b).To(MatchJSON(`{"in": "Hello"}`))
+
+		policy.RedactFields = []string{"IN"}
+		b, err = policy.Marshal(&gen.PingRequest{In: "Hello"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(b).To(MatchJSON(`{"in": "******"}`))
+	})
+	It("Redacts fields by name at any depth", func() {
+		var value interface{}
+		Expect(json.Unmarshal([]byte(`{"user": {"name": "Ann", "Password": "p4ss"}, "sessions": [{"token": "t0k"}]}`), &value)).To(Succeed())
+		b, err := json.Marshal(policy.redact(value, nil))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(b).To(MatchJSON(`{"user": {"name": "Ann", "Password": "******"}, "sessions": [{"token": "******"}]}`))
+	})
+	It("Redacts fields by option", func() {
+		options := &descriptorpb.FieldOptions{}
+		Expect(proto.SetExtension(options, sensitiveOption, proto.Bool(true))).To(Succeed())
+		file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+			Name:    proto.String("test/login.proto"),
+			Package: proto.String("test"),
+			Syntax:  proto.String("proto3"),
+			MessageType: []*descriptorpb.DescriptorProto{{
+				Name: proto.String("Login"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{Name: proto.String("user"), JsonName: proto.String("user"), Number: proto.Int32(1), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()},
+					{Name: proto.String("pin"), JsonName: proto.String("pin"), Number: proto.Int32(2), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(), Options: options},
+				},
+			}},
+		}, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		var value interface{}
+		Expect(json.Unmarshal([]byte(`{"user": "ann", "pin": "1234"}`), &value)).To(Succeed())
+		b, err := json.Marshal(policy.redact(value, file.Messages().Get(0)))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(b).To(MatchJSON(`{"user": "ann", "pin": "******"}`))
+	})
+	It("Truncates large payloads", func() {
+		policy.MaxSize = 5
+		b, err := policy.Marshal(&gen.PingRequest{In: "Hello"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(b).To(MatchJSON(`"{\"in\"... (truncated, 14 bytes)"`))
+	})
+	It("Truncates large payloads without splitting characters", func() {
+		// The limit falls in the middle of the 2 bytes of é.
+		policy.MaxSize = 9
+		b, err := policy.Marshal(&gen.PingRequest{In: "Héllo"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(b).To(MatchJSON(`"{\"in\":\"H... (truncated, 15 bytes)"`))
+	})
+	It("Logs the payloads of the calls decided by the policy", func() {
+		logger, hook := logrustest.NewNullLogger()
+		policy.RedactFields = []string{"out"}
+		l := NewLogger(logrus.NewEntry(logger), policy)
+		call := func(method string) {
+			_, err := l.UnaryServerInterceptor()(context.Background(), &gen.PingRequest{In: "Hello"}, &grpc.UnaryServerInfo{FullMethod: method},
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					return &gen.PingResponse{Out: "Hello"}, nil
+				})
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		call("/api.PingService/Ping")
+		Expect(hook.AllEntries()).To(HaveLen(2))
+		request, err := json.Marshal(hook.AllEntries()[0].Data["grpc.request.content"])
+		Expect(err).ToNot(HaveOccurred())
+		Expect(request).To(MatchJSON(`{"in": "Hello"}`))
+		response, err := json.Marshal(hook.AllEntries()[1].Data["grpc.response.content"])
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response).To(MatchJSON(`{"out": "******"}`))
+
+		hook.Reset()
+		call("/grpc.health.v1.Health/Check")
+		l.SetEnabled(false)
+		call("/api.PingService/Ping")
+		Expect(hook.AllEntries()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,191 @@
+package payload
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
+	"github.com/gogo/gateway"
+	"github.com/golang/protobuf/proto"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"math/rand"
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+const redacted = "******"
+
+// Policy deciding which payloads are logged by the GRPC Server, Gateway and Connections (when their LOG_PAYLOAD setting is enabled), and how.
+// Methods are matched against the include and exclude patterns (e.g. /api.PingService/* or /api.PingService/Ping, see path.Match()),
+// logged payloads are redacted and truncated.
+type Policy struct {
+	config.Result
+
+	Include       []string `env:"INCLUDE"`                                                                                        // Comma separated patterns of the methods whose payload is logged (e.g. /api.PingService/*). Empty includes all methods.
+	Exclude       []string `env:"EXCLUDE" default:"/grpc.health.v1.Health/*,/grpc.reflection.*/*"`                                // Comma separated patterns of the methods whose payload isn't logged, even if included.
+	SampleRate    float64  `env:"SAMPLE_RATE" default:"1" min:"0" max:"1"`                                                        // Fraction of the calls whose payload is logged, between 0 and 1.
+	MaxSize       int      `env:"MAX_SIZE" default:"4096" min:"0"`                                                                // Maximum size of a logged payload in bytes, larger payloads are truncated. Zero means no limit.
+	RedactFields  []string `env:"REDACT_FIELDS" default:"password,secret,token,access_token,refresh_token,api_key,authorization"` // Comma separated names of the fields whose value is redacted, at any depth (case insensitive).
+	RedactOptions []string `env:"REDACT_OPTIONS" default:"sensitive"`                                                             // Comma separated names of the boolean field options marking the fields whose value is redacted (e.g. sensitive for [(sensitive) = true]).
+}
+
+// Initializes the policy from environment variables. The policy is shared by all providers, its settings are prefixed by GRPC_PAYLOAD_LOG.
+func NewPolicyFromEnv() *Policy {
+	p := &Policy{}
+	_ = config.Load("GRPC_PAYLOAD_LOG", p)
+
+	logrus.WithFields(config.Fields(p)).Debug("GRPC payload logging Policy initialized")
+
+	return p
+}
+
+// Reloads the policy from environment variables, for providers applying changes while running.
+func ReloadPolicy() (*Policy, error) {
+	p := &Policy{}
+	if err := config.Reload("GRPC_PAYLOAD_LOG", p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Returns the policy with default settings, used by providers without policy.
+func DefaultPolicy() *Policy {
+	p := &Policy{}
+	_ = config.SetDefaults(p)
+	return p
+}
+
+// Checks the method patterns are valid.
+func (p *Policy) Validate() error {
+	for _, pattern := range append(append([]string{}, p.Include...), p.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid method pattern %s: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Decides whether the payload of a call to the method is logged: the method should be included and not excluded,
+// and the call is sampled according to the sample rate.
+func (p *Policy) Decide(fullMethodName string) bool {
+	if len(p.Include) > 0 && !matchAny(p.Include, fullMethodName) {
+		return false
+	}
+	if matchAny(p.Exclude, fullMethodName) {
+		return false
+	}
+	return p.SampleRate >= 1 || rand.Float64() < p.SampleRate
+}
+
+func matchAny(patterns []string, fullMethodName string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, fullMethodName); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Marshals the message to JSON, with its sensitive fields redacted.
+// Messages larger than the maximum size are truncated, and logged as a string.
+func (p *Policy) Marshal(pb proto.Message) ([]byte, error) {
+	b, err := (&gateway.JSONPb{EnumsAsInts: true, EmitDefaults: true, OrigName: true}).Marshal(pb)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if b, err = json.Marshal(p.redact(value, proto.MessageReflect(pb).Descriptor())); err != nil {
+		return nil, err
+	}
+
+	if p.MaxSize > 0 && len(b) > p.MaxSize {
+		// Backs up to the start of a rune, so a multi-byte character isn't split.
+		size := p.MaxSize
+		for size > 0 && !utf8.RuneStart(b[size]) {
+			size--
+		}
+		return json.Marshal(fmt.Sprintf("%s... (truncated, %d bytes)", b[:size], len(b)))
+	}
+	return b, nil
+}
+
+// Redacts the sensitive fields of a JSON value, described by the message descriptor if known.
+// Fields are redacted by name at any depth (including the keys of maps and structs), or by option if described.
+func (p *Policy) redact(value interface{}, md protoreflect.MessageDescriptor) interface{} {
+	switch value := value.(type) {
+	case []interface{}:
+		for i, item := range value {
+			value[i] = p.redact(item, md)
+		}
+	case map[string]interface{}:
+		for key, item := range value {
+			var field protoreflect.FieldDescriptor
+			if md != nil {
+				if field = md.Fields().ByName(protoreflect.Name(key)); field == nil {
+					field = md.Fields().ByJSONName(key)
+				}
+			}
+			switch {
+			case p.isSensitive(key, field):
+				value[key] = redacted
+			case field == nil:
+				value[key] = p.redact(item, nil)
+			case field.IsMap():
+				value[key] = p.redactMap(item, field.MapValue().Message())
+			default:
+				value[key] = p.redact(item, field.Message())
+			}
+		}
+	}
+	return value
+}
+
+// Redacts the entries of a map whose key is sensitive, and the sensitive fields of its values.
+func (p *Policy) redactMap(value interface{}, md protoreflect.MessageDescriptor) interface{} {
+	entries, ok := value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+	for key, item := range entries {
+		if p.isSensitive(key, nil) {
+			entries[key] = redacted
+			continue
+		}
+		entries[key] = p.redact(item, md)
+	}
+	return entries
+}
+
+func (p *Policy) isSensitive(key string, field protoreflect.FieldDescriptor) bool {
+	for _, name := range p.RedactFields {
+		if strings.EqualFold(name, key) || (field != nil && strings.EqualFold(name, string(field.Name()))) {
+			return true
+		}
+	}
+	if field == nil || len(p.RedactOptions) == 0 {
+		return false
+	}
+
+	sensitive := false
+	field.Options().ProtoReflect().Range(func(option protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		if !option.IsExtension() || option.Kind() != protoreflect.BoolKind || !value.Bool() {
+			return true
+		}
+		for _, name := range p.RedactOptions {
+			if name == string(option.Name()) || name == string(option.FullName()) {
+				sensitive = true
+				return false
+			}
+		}
+		return true
+	})
+	return sensitive
+}
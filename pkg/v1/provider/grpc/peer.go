@@ -0,0 +1,75 @@
+package grpc
+
+import (
+	"context"
+	"crypto/x509"
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	"github.com/grpc-ecosystem/go-grpc-middleware/tags"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// Identity of a client, verified with the certificate it presented (mTLS, see the GRPC_TLS_CLIENT_CA_FILE setting).
+type PeerIdentity struct {
+	CommonName  string   // Common name of the subject of the certificate.
+	DNSNames    []string // DNS names of the certificate.
+	URIs        []string // URIs of the certificate, like SPIFFE IDs (e.g. "spiffe://cluster.local/ns/prod/sa/users").
+	Certificate *x509.Certificate
+}
+
+type peerIdentityKey struct{}
+
+// Returns the verified identity of the client of a request, if it presented a certificate.
+// Can be used to authorize requests from other services.
+func PeerIdentityFromContext(ctx context.Context) (*PeerIdentity, bool) {
+	if identity, ok := ctx.Value(peerIdentityKey{}).(*PeerIdentity); ok {
+		return identity, true
+	}
+	identity := verifiedPeerIdentity(ctx)
+	return identity, identity != nil
+}
+
+// Adds the verified identity of the client to the context of the request, and its common name to the tags logged with the request.
+func withPeerIdentity(ctx context.Context) context.Context {
+	identity := verifiedPeerIdentity(ctx)
+	if identity == nil {
+		return ctx
+	}
+	grpc_ctxtags.Extract(ctx).Set("peer.common_name", identity.CommonName)
+	return context.WithValue(ctx, peerIdentityKey{}, identity)
+}
+
+// Returns the identity of the peer of the connection, or nil if it didn't present a verified certificate.
+func verifiedPeerIdentity(ctx context.Context) *PeerIdentity {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	// Only verified chains are trusted, the peer certificates of unverified clients are left out.
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return nil
+	}
+
+	cert := tlsInfo.State.VerifiedChains[0][0]
+	identity := &PeerIdentity{
+		CommonName:  cert.Subject.CommonName,
+		DNSNames:    cert.DNSNames,
+		Certificate: cert,
+	}
+	for _, uri := range cert.URIs {
+		identity.URIs = append(identity.URIs, uri.String())
+	}
+	return identity
+}
+
+func peerIdentityUnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(withPeerIdentity(ctx), req)
+}
+
+func peerIdentityStreamServerInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	wrapped := grpc_middleware.WrapServerStream(stream)
+	wrapped.WrappedContext = withPeerIdentity(stream.Context())
+	return handler(srv, wrapped)
+}
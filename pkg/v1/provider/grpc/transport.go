@@ -0,0 +1,41 @@
+package grpc
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+// Returns the server options tuning the transport (keepalive, message sizes, concurrency and buffers).
+// Sizes of zero are left out, so the GRPC defaults apply, just like durations of zero.
+func (c *Config) transportOptions() []grpc.ServerOption {
+	opts := []grpc.ServerOption{
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			MaxConnectionIdle:     c.MaxConnectionIdle,
+			MaxConnectionAge:      c.MaxConnectionAge,
+			MaxConnectionAgeGrace: c.MaxConnectionAgeGrace,
+			Time:                  c.KeepaliveTime,
+			Timeout:               c.KeepaliveTimeout,
+		}),
+		// Clients (like the GRPC Connection Provider) may ping without active streams, to keep idle connections alive.
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             c.KeepaliveMinTime,
+			PermitWithoutStream: c.KeepalivePermitWithoutStream,
+		}),
+	}
+	if c.MaxRecvMsgSize > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(c.MaxRecvMsgSize))
+	}
+	if c.MaxSendMsgSize > 0 {
+		opts = append(opts, grpc.MaxSendMsgSize(c.MaxSendMsgSize))
+	}
+	if c.MaxConcurrentStreams > 0 {
+		opts = append(opts, grpc.MaxConcurrentStreams(c.MaxConcurrentStreams))
+	}
+	if c.WriteBufferSize > 0 {
+		opts = append(opts, grpc.WriteBufferSize(c.WriteBufferSize))
+	}
+	if c.ReadBufferSize > 0 {
+		opts = append(opts, grpc.ReadBufferSize(c.ReadBufferSize))
+	}
+	return opts
+}
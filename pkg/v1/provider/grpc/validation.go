@@ -0,0 +1,107 @@
+package grpc
+
+import (
+	"context"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Implemented by the messages generated by protoc-gen-validate, or by any message validating itself.
+type Validator interface {
+	Validate() error
+}
+
+// Implemented by the messages generated by protoc-gen-validate (v0.6+), reporting all violations instead of the first one.
+// Preferred over Validator when a message implements both.
+type AllValidator interface {
+	ValidateAll() error
+}
+
+// Errors of protoc-gen-validate describing the violation of a field, whose cause is the error of an embedded message if any.
+type fieldError interface {
+	Field() string
+	Reason() string
+	Cause() error
+}
+
+// Errors of protoc-gen-validate grouping the violations reported by ValidateAll().
+type multiError interface {
+	AllErrors() []error
+}
+
+// Returns the error of a request that failed validation, with the InvalidArgument code and the violations of its fields as BadRequest details.
+// Errors that already are a GRPC status are returned as is. Can be used by handlers validating requests further.
+func ValidationError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	st := status.New(codes.InvalidArgument, err.Error())
+	if detailed, detailsErr := st.WithDetails(&errdetails.BadRequest{FieldViolations: fieldViolations("", err)}); detailsErr == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// Lists the violations of the error, naming the fields by their path (e.g. address.zip_code).
+func fieldViolations(path string, err error) []*errdetails.BadRequest_FieldViolation {
+	switch err := err.(type) {
+	case multiError:
+		var violations []*errdetails.BadRequest_FieldViolation
+		for _, e := range err.AllErrors() {
+			violations = append(violations, fieldViolations(path, e)...)
+		}
+		return violations
+	case fieldError:
+		field := err.Field()
+		if path != "" {
+			field = path + "." + field
+		}
+		switch cause := err.Cause().(type) {
+		case multiError, fieldError:
+			return fieldViolations(field, cause)
+		}
+		return []*errdetails.BadRequest_FieldViolation{{Field: field, Description: err.Reason()}}
+	default:
+		return []*errdetails.BadRequest_FieldViolation{{Field: path, Description: err.Error()}}
+	}
+}
+
+// Validates the message if it implements AllValidator or Validator.
+func validate(m interface{}) error {
+	switch m := m.(type) {
+	case AllValidator:
+		return ValidationError(m.ValidateAll())
+	case Validator:
+		return ValidationError(m.Validate())
+	}
+	return nil
+}
+
+// Rejects invalid requests before they reach the handler.
+func validationUnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := validate(req); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// Rejects invalid messages as the handler receives them, failing the stream.
+func validationStreamServerInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &validatingServerStream{ServerStream: stream})
+}
+
+type validatingServerStream struct {
+	grpc.ServerStream
+}
+
+func (s *validatingServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return validate(m)
+}
@@ -0,0 +1,40 @@
+package httpserver
+
+import (
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/tlsconfig"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+// Configuration of the HTTP server of a Provider.
+type Config struct {
+	config.Result
+
+	ReadTimeout       time.Duration     `env:"READ_TIMEOUT" default:"30" min:"0"`          // Maximum duration for reading an entire request, including the body. Zero means no timeout.
+	ReadHeaderTimeout time.Duration     `env:"READ_HEADER_TIMEOUT" default:"10" min:"0"`   // Maximum duration for reading the request headers. Zero means the ReadTimeout is used.
+	WriteTimeout      time.Duration     `env:"WRITE_TIMEOUT" default:"60" min:"0"`         // Maximum duration before timing out writes of the response. Zero means no timeout.
+	IdleTimeout       time.Duration     `env:"IDLE_TIMEOUT" default:"120" min:"0"`         // Maximum duration to wait for the next request on a keep-alive connection. Zero means the ReadTimeout is used.
+	MaxHeaderBytes    int               `env:"MAX_HEADER_BYTES" default:"1048576" min:"0"` // Maximum size of the request headers.
+	HTTP2Enabled      bool              `env:"HTTP2_ENABLED" default:"true"`               // Whether or not HTTP/2 is negotiated with clients. Only applies when TLS is enabled.
+	TLS               *tlsconfig.Config // TLS (and mTLS) configuration. The server uses plain HTTP if nil or disabled.
+	SocketName        string            `env:"SOCKET_NAME"` // Name of the systemd socket to serve on when socket activated (see FileDescriptorName= in systemd.socket), instead of binding the port.
+}
+
+// Initializes the configuration from environment variables, using the prefix of the Provider (e.g. PROBES results in PROBES_READ_TIMEOUT).
+func NewConfigFromEnv(prefix string) *Config {
+	c := &Config{}
+	_ = config.Load(prefix, c)
+	c.TLS = tlsconfig.NewConfigFromEnv(prefix)
+
+	logrus.WithFields(config.Fields(c)).Debugf("%s HTTP server Config initialized", prefix)
+
+	return c
+}
+
+// Configuration used by Providers that weren't given one, matching the defaults of NewConfigFromEnv().
+func defaultConfig() *Config {
+	c := &Config{}
+	_ = config.SetDefaults(c) // The default tags are covered by the tests.
+	return c
+}
@@ -0,0 +1,183 @@
+package httpserver
+
+import (
+	"context"
+	"crypto/tls"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/listener"
+	"github.com/sirupsen/logrus"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// HTTP Server.
+// Base for RunProviders that serve HTTP, embedded instead of the AbstractRunProvider.
+// Takes care of timeouts, header limits, TLS (with certificate reloading), HTTP/2 and graceful shutdown.
+// The Run() method of the embedding Provider builds its handler and calls Serve().
+// Providers that are mounted on another server (see the Admin Provider) don't listen on a port of their own.
+type Server struct {
+	provider.AbstractRunProvider
+
+	mu       sync.Mutex
+	name     string
+	srv      *http.Server
+	mounted  bool
+	listener net.Listener
+	addr     net.Addr
+}
+
+// Makes the Provider serve on the given listener instead of binding its configured port (e.g. a listener opened by a test or sidecar).
+// Should be called before the Provider runs.
+func (s *Server) SetListener(listener net.Listener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.listener = listener
+}
+
+// Returns the address the Provider is listening on once it is running (e.g. to discover an ephemeral port), or nil otherwise.
+// Mounted Providers don't listen on an address of their own.
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.addr
+}
+
+// Marks the Provider as mounted on another server, which serves its handlers instead (see the Admin Provider).
+// Should be called before the Provider runs.
+func (s *Server) SetMounted(mounted bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.mounted = mounted
+}
+
+// Returns true if the Provider is mounted on another server.
+func (s *Server) IsMounted() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.mounted
+}
+
+// Serves the handler on the given port, blocking until the server is closed.
+// Port 0 results in an ephemeral port (see Addr()). The port isn't bound if a listener was set, or a systemd socket was configured and passed.
+// The name is used in logs (e.g. "Probes Provider"). A nil configuration results in the default timeouts without TLS.
+// The Provider is only marked as running once the port is bound, so a failure to listen is returned right away.
+// A mounted Provider is marked as running without listening, and blocks until it is closed.
+func (s *Server) Serve(name string, port int, config *Config, handler http.Handler, logEntry *logrus.Entry) error {
+	if s.IsMounted() {
+		s.SetRunning(true)
+		logEntry.Infof("%s mounted on the Admin server", name)
+		<-s.Done()
+		return nil
+	}
+	if config == nil {
+		config = defaultConfig()
+	}
+
+	tlsConfig, err := config.TLS.ServerTLSConfig()
+	if err != nil {
+		logEntry.WithError(err).Errorf("%s TLS configuration failed", name)
+		return err
+	}
+
+	srv := &http.Server{
+		Handler:           handler,
+		ReadTimeout:       config.ReadTimeout,
+		ReadHeaderTimeout: config.ReadHeaderTimeout,
+		WriteTimeout:      config.WriteTimeout,
+		IdleTimeout:       config.IdleTimeout,
+		MaxHeaderBytes:    config.MaxHeaderBytes,
+		TLSConfig:         tlsConfig,
+	}
+	if !config.HTTP2Enabled {
+		// A non-nil, empty map disables HTTP/2 negotiation.
+		srv.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
+	}
+
+	s.mu.Lock()
+	injected := s.listener
+	s.mu.Unlock()
+	l, err := listener.Listen(port, config.SocketName, injected)
+	if err != nil {
+		logEntry.WithError(err).Errorf("%s launch failed", name)
+		return err
+	}
+
+	s.mu.Lock()
+	s.name, s.srv, s.addr = name, srv, l.Addr()
+	s.mu.Unlock()
+	s.SetRunning(true)
+
+	logEntry.WithFields(logrus.Fields{
+		"addr": l.Addr().String(),
+		"tls":  tlsConfig != nil,
+	}).Infof("%s launched", name)
+	if tlsConfig != nil {
+		// The certificate is provided by the TLS configuration.
+		err = srv.ServeTLS(l, "", "")
+	} else {
+		err = srv.Serve(l)
+	}
+	if err != http.ErrServerClosed {
+		logEntry.WithError(err).Errorf("%s launch failed", name)
+		return err
+	}
+
+	return nil
+}
+
+// Resets the server once the Run() method of the embedding Provider returned, so it can call Serve() again.
+// Used by the Providers implementing provider.Restartable. A listener set with SetListener() is closed once it was served on, so the port is bound instead.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	if s.srv != nil {
+		s.listener = nil
+	}
+	s.srv, s.addr = nil, nil
+	s.mu.Unlock()
+
+	s.AbstractRunProvider.Reset()
+}
+
+// Closes the HTTP server, waiting for in-flight requests to finish.
+func (s *Server) Close() error {
+	return s.CloseContext(context.Background())
+}
+
+// Gracefully shuts down the HTTP server: in-flight requests are completed until the context is done, after which remaining connections are closed.
+func (s *Server) CloseContext(ctx context.Context) error {
+	s.mu.Lock()
+	name, srv := s.name, s.srv
+	s.mu.Unlock()
+	if srv == nil {
+		return s.AbstractRunProvider.Close()
+	}
+
+	err := srv.Shutdown(ctx)
+	if err != nil {
+		logrus.WithError(err).Errorf("Error while closing %s server", name)
+		_ = srv.Close()
+	}
+
+	if closeErr := s.AbstractRunProvider.Close(); closeErr != nil {
+		return closeErr
+	}
+	return err
+}
+
+// Stops keeping connections alive as soon as the shutdown sequence starts, so clients reconnect to other instances while requests are drained.
+func (s *Server) OnShutdown(phase provider.ShutdownPhase) {
+	if phase != provider.ShutdownPhaseDrain {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.srv != nil {
+		s.srv.SetKeepAlivesEnabled(false)
+	}
+}
@@ -0,0 +1,259 @@
+package httpserver
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"github.azc.ext.hp.com/hp-business-platform/lib-core-go/pkg/v1/test"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/tlsconfig"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHTTPServer(t *testing.T) {
+	RegisterFailHandlerWithT(t, Fail)
+	RunSpecsWithDefaultAndCustomReporters(t, "HTTP server test", test.LoadCustomReporters("../../test_provider_httpserver.xml"))
+}
+
+var _ = Describe("HTTP server", func() {
+	var dir string
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "httpserver")
+		Expect(err).ToNot(HaveOccurred())
+	})
+	AfterEach(func() {
+		_ = os.RemoveAll(dir)
+	})
+
+	It("Serves plain HTTP with the default configuration", func() {
+		p := &TestServer{}
+		runServer(p)
+
+		res, err := http.Get(fmt.Sprintf("http://localhost:%d/", port(p)))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+
+		Expect(p.Close()).To(Succeed())
+		Expect(p.IsRunning()).To(BeFalse())
+	})
+	It("Uses the same defaults as NewConfigFromEnv", func() {
+		Expect(defaultConfig()).To(Equal(&Config{
+			ReadTimeout:       30 * time.Second,
+			ReadHeaderTimeout: 10 * time.Second,
+			WriteTimeout:      60 * time.Second,
+			IdleTimeout:       120 * time.Second,
+			MaxHeaderBytes:    1 << 20,
+			HTTP2Enabled:      true,
+		}))
+		config := NewConfigFromEnv("HTTPSERVER_TEST")
+		config.TLS = nil
+		Expect(config).To(Equal(defaultConfig()))
+	})
+	It("Fails to run if the port is already in use", func() {
+		p1 := &TestServer{}
+		runServer(p1)
+		defer p1.Close()
+
+		p2 := &TestServer{port: port(p1)}
+		Expect(p2.Run()).ToNot(Succeed())
+		Expect(p2.IsRunning()).To(BeFalse())
+		Expect(p2.Addr()).To(BeNil())
+	})
+	It("Serves on an injected listener instead of binding its port", func() {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		p := &TestServer{port: 1}
+		p.SetListener(l)
+		runServer(p)
+		defer p.Close()
+
+		Expect(p.Addr()).To(Equal(l.Addr()))
+		res, err := http.Get(fmt.Sprintf("http://%s/", l.Addr()))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+	})
+	It("Serves HTTP/2 over TLS and reloads a rotated certificate", func() {
+		certFile, keyFile := writeCert(dir, "server", "first", nil)
+		config := defaultConfig()
+		config.TLS = &tlsconfig.Config{Enabled: true, CertFile: certFile, KeyFile: keyFile, ReloadInterval: time.Nanosecond}
+		p := &TestServer{config: config}
+		runServer(p)
+		defer p.Close()
+
+		res := getTLS(port(p), nil)
+		Expect(res.ProtoMajor).To(Equal(2))
+		Expect(res.TLS.PeerCertificates[0].Subject.CommonName).To(Equal("first"))
+
+		// Make sure the modification time changes, even on file systems with a coarse resolution.
+		time.Sleep(10 * time.Millisecond)
+		writeCert(dir, "server", "second", nil)
+		future := time.Now().Add(time.Second)
+		Expect(os.Chtimes(certFile, future, future)).To(Succeed())
+		res = getTLS(port(p), nil)
+		Expect(res.TLS.PeerCertificates[0].Subject.CommonName).To(Equal("second"))
+	})
+	It("Requires a client certificate when a client CA is configured", func() {
+		caCert, caKey := newCA()
+		caFile := filepath.Join(dir, "ca.pem")
+		Expect(ioutil.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caCert.Raw}), 0600)).To(Succeed())
+		certFile, keyFile := writeCert(dir, "server", "server", nil)
+		clientCertFile, clientKeyFile := writeCert(dir, "client", "client", &signer{cert: caCert, key: caKey})
+
+		config := defaultConfig()
+		config.HTTP2Enabled = false
+		config.TLS = &tlsconfig.Config{Enabled: true, CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile}
+		p := &TestServer{config: config}
+		runServer(p)
+		defer p.Close()
+
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+		_, err := client.Get(fmt.Sprintf("https://localhost:%d/", port(p)))
+		Expect(err).To(HaveOccurred())
+
+		clientCert, err := tls.LoadX509KeyPair(clientCertFile, clientKeyFile)
+		Expect(err).ToNot(HaveOccurred())
+		res := getTLS(port(p), []tls.Certificate{clientCert})
+		Expect(res.ProtoMajor).To(Equal(1))
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+	})
+	It("Shuts down gracefully, waiting for in-flight requests", func() {
+		release := make(chan struct{})
+		p := &TestServer{handler: http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			<-release
+		})}
+		runServer(p)
+
+		done := make(chan int)
+		go func() {
+			defer GinkgoRecover()
+			res, err := http.Get(fmt.Sprintf("http://localhost:%d/", port(p)))
+			Expect(err).ToNot(HaveOccurred())
+			done <- res.StatusCode
+		}()
+		time.Sleep(50 * time.Millisecond)
+
+		p.OnShutdown(provider.ShutdownPhaseDrain)
+		closed := make(chan error)
+		go func() {
+			closed <- p.CloseContext(context.Background())
+		}()
+		Consistently(closed).ShouldNot(Receive())
+		close(release)
+		Eventually(done).Should(Receive(Equal(http.StatusOK)))
+		Eventually(closed).Should(Receive(BeNil()))
+	})
+})
+
+// Runs the server in the background and waits until it is running.
+func runServer(p *TestServer) {
+	go func() {
+		_ = p.Run()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	Expect(provider.WaitReady(ctx, p)).To(Succeed())
+}
+
+// Returns the port the server is listening on.
+func port(p *TestServer) int {
+	return p.Addr().(*net.TCPAddr).Port
+}
+
+// Performs a GET request over TLS (trusting any server certificate), negotiating HTTP/2 if possible.
+func getTLS(port int, certificates []tls.Certificate) *http.Response {
+	transport := &http.Transport{
+		TLSClientConfig:   &tls.Config{InsecureSkipVerify: true, Certificates: certificates},
+		ForceAttemptHTTP2: true,
+		DisableKeepAlives: true,
+	}
+	res, err := (&http.Client{Transport: transport}).Get(fmt.Sprintf("https://localhost:%d/", port))
+	Expect(err).ToNot(HaveOccurred())
+	return res
+}
+
+type signer struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// Creates a self-signed CA.
+func newCA() (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).ToNot(HaveOccurred())
+	cert, err := x509.ParseCertificate(der)
+	Expect(err).ToNot(HaveOccurred())
+	return cert, key
+}
+
+// Writes a certificate and key with the given common name, signed by the signer (or self-signed if nil).
+func writeCert(dir, name, commonName string, s *signer) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	parent, parentKey := template, key
+	if s != nil {
+		parent, parentKey = s.cert, s.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	Expect(err).ToNot(HaveOccurred())
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	Expect(err).ToNot(HaveOccurred())
+
+	certFile, keyFile := filepath.Join(dir, name+".pem"), filepath.Join(dir, name+"-key.pem")
+	Expect(ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)).To(Succeed())
+	Expect(ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)).To(Succeed())
+	return certFile, keyFile
+}
+
+// HTTP server responding with 200 OK (or using the given handler), on an ephemeral port unless one is given.
+type TestServer struct {
+	Server
+
+	port    int
+	config  *Config
+	handler http.Handler
+}
+
+func (p *TestServer) Run() error {
+	handler := p.handler
+	if handler == nil {
+		handler = http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {})
+	}
+	return p.Serve("Test Server", p.port, p.config, handler, logrus.WithField("port", p.port))
+}
@@ -1,46 +1,30 @@
 package jaeger
 
 import (
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
 	"github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
-)
-
-const (
-	defaultHost = "127.0.0.1"
-	defaultPort = 6831
 )
 
 // Config ...
 type Config struct {
-	Enabled bool   // Whether or not a connection should be made and events should be emitted.
-	Host    string // Hostname where to find the Jaeger agent.
-	Port    int    // Port where to find the Jaeger agent.
+	config.Result
+
+	Enabled bool   `env:"ENABLED" default:"true"`                        // Whether or not a connection should be made and events should be emitted.
+	Host    string `env:"AGENT_HOST" default:"127.0.0.1"`                // Hostname where to find the Jaeger agent.
+	Port    int    `env:"AGENT_PORT" default:"6831" min:"1" max:"65535"` // Port where to find the Jaeger agent.
 }
 
 // Initializes the configuration from environment variables.
 func NewConfigFromEnv() *Config {
-	v := viper.New()
-	v.SetEnvPrefix("JAEGER")
-	v.AutomaticEnv()
+	c := &Config{}
+	_ = config.Load("JAEGER", c)
 
-	v.SetDefault("ENABLED", true)
-	enabled := v.GetBool("ENABLED")
+	logrus.WithFields(config.Fields(c)).Debug("Jaeger Config Initialized")
 
-	v.SetDefault("AGENT_HOST", defaultHost)
-	host := v.GetString("AGENT_HOST")
-
-	v.SetDefault("AGENT_PORT", defaultPort)
-	port := v.GetInt("AGENT_PORT")
-
-	logrus.WithFields(logrus.Fields{
-		"enabled": enabled,
-		"port":    port,
-		"host":    host,
-	}).Debug("Jaeger Config Initialized")
+	return c
+}
 
-	return &Config{
-		Enabled: enabled,
-		Host:    host,
-		Port:    port,
-	}
+// Returns the invalid settings of the Jaeger configuration, if any (see provider.Configured).
+func (p *Jaeger) ConfigError() error {
+	return config.Err(p.Config)
 }
@@ -23,7 +23,7 @@ type Jaeger struct {
 }
 
 // Creates a Jaeger Provider.
-// Uses the AppProvider to send the service name to Jaeger.
+// Uses the AppProvider to send the service name and the identity of the instance to Jaeger.
 func New(config *Config, appProvider *app.App) *Jaeger {
 	return &Jaeger{
 		Config:      config,
@@ -31,14 +31,32 @@ func New(config *Config, appProvider *app.App) *Jaeger {
 	}
 }
 
+// Jaeger depends on the App Provider for the service name.
+func (p *Jaeger) Dependencies() []provider.Provider {
+	return []provider.Provider{p.appProvider}
+}
+
+// Looks up the App Provider in the Stack, if it wasn't passed to New().
+func (p *Jaeger) Resolve(registry provider.Registry) error {
+	if p.appProvider == nil && !registry.Lookup(&p.appProvider) {
+		return fmt.Errorf("Jaeger Provider requires an App Provider")
+	}
+	return nil
+}
+
 // Creates the global tracer that reports tracing data to Jaeger.
 func (p *Jaeger) Init() error {
 	metrics := prometheus.New()
 
-	// Initialize the tracing configuration.
+	// Initialize the tracing configuration, tagging the tracer with the identity of the instance.
+	var tags []opentracing.Tag
+	for key, value := range p.appProvider.Identity().Tags() {
+		tags = append(tags, opentracing.Tag{Key: key, Value: value})
+	}
 	conf := config.Configuration{
 		ServiceName: p.appProvider.Name(),
 		Disabled:    !p.Config.Enabled,
+		Tags:        tags,
 		Sampler: &config.SamplerConfig{
 			Type:  "const",
 			Param: 1,
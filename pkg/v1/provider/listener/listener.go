@@ -0,0 +1,59 @@
+package listener
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// First file descriptor passed by systemd socket activation.
+const systemdFirstFD = 3
+
+// Returns the listener a server should serve on, which is (in order of preference):
+// the given listener (e.g. injected by a test or sidecar setup), the systemd socket with the given name (if the process was socket activated), or a new TCP listener on the port.
+// Port 0 results in an ephemeral port, which can be discovered using the Addr() method of the returned listener.
+func Listen(port int, socketName string, injected net.Listener) (net.Listener, error) {
+	if injected != nil {
+		return injected, nil
+	}
+	if socketName != "" {
+		listener, err := Systemd(socketName)
+		if err != nil {
+			return nil, err
+		}
+		if listener != nil {
+			return listener, nil
+		}
+	}
+	return net.Listen("tcp", fmt.Sprintf(":%d", port))
+}
+
+// Returns the listener passed by systemd socket activation with the given name (see FileDescriptorName= in systemd.socket).
+// Returns nil if the process wasn't socket activated, or no socket with the name was passed.
+func Systemd(name string) (net.Listener, error) {
+	if os.Getenv("LISTEN_PID") != strconv.Itoa(os.Getpid()) {
+		return nil, nil
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return nil, nil
+	}
+
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	for i := 0; i < count && i < len(names); i++ {
+		if names[i] != name {
+			continue
+		}
+		file := os.NewFile(uintptr(systemdFirstFD+i), name)
+		defer file.Close()
+
+		listener, err := net.FileListener(file)
+		if err != nil {
+			return nil, fmt.Errorf("systemd socket %s is not a listener: %w", name, err)
+		}
+		return listener, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,59 @@
+package listener
+
+import (
+	"github.azc.ext.hp.com/hp-business-platform/lib-core-go/pkg/v1/test"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"net"
+	"os"
+	"strconv"
+	"testing"
+)
+
+func TestListener(t *testing.T) {
+	RegisterFailHandlerWithT(t, Fail)
+	RunSpecsWithDefaultAndCustomReporters(t, "Listener test", test.LoadCustomReporters("../../test_provider_listener.xml"))
+}
+
+var _ = Describe("Listener", func() {
+	AfterEach(func() {
+		_ = os.Unsetenv("LISTEN_PID")
+		_ = os.Unsetenv("LISTEN_FDS")
+		_ = os.Unsetenv("LISTEN_FDNAMES")
+	})
+
+	It("Listens on an ephemeral port", func() {
+		l, err := Listen(0, "", nil)
+		Expect(err).ToNot(HaveOccurred())
+		defer l.Close()
+		Expect(l.Addr().(*net.TCPAddr).Port).ToNot(BeZero())
+	})
+	It("Prefers the injected listener", func() {
+		injected, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		defer injected.Close()
+
+		l, err := Listen(1, "http", injected)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(l).To(BeIdenticalTo(injected))
+	})
+	It("Falls back to the port if the process wasn't socket activated", func() {
+		l, err := Systemd("http")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(l).To(BeNil())
+
+		l, err = Listen(0, "http", nil)
+		Expect(err).ToNot(HaveOccurred())
+		defer l.Close()
+		Expect(l.Addr().(*net.TCPAddr).Port).ToNot(BeZero())
+	})
+	It("Ignores sockets passed to another process or with another name", func() {
+		Expect(os.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))).To(Succeed())
+		Expect(os.Setenv("LISTEN_FDS", "1")).To(Succeed())
+		Expect(os.Setenv("LISTEN_FDNAMES", "http")).To(Succeed())
+		Expect(Systemd("http")).To(BeNil())
+
+		Expect(os.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))).To(Succeed())
+		Expect(Systemd("grpc")).To(BeNil())
+	})
+})
@@ -1,21 +1,18 @@
 package logrus
 
 import (
+	"fmt"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
 	"github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
 	"io"
 	"os"
 	"reflect"
 )
 
-const (
-	defaultLevel     = "info"
-	defaultFormatter = "json"
-	defaultOutput    = "stderr"
-)
-
 // Config ...
 type Config struct {
+	config.Result
+
 	Level     logrus.Level     // Logging level (trace, debug, info, etc).
 	Formatter logrus.Formatter // Log formatter (text or json).
 	Output    io.Writer        // Output writer (stderr or stdout).
@@ -24,7 +21,7 @@ type Config struct {
 // Initializes the configuration from environment variables.
 // Basically just logs the output of ParseEnv().
 func NewConfigFromEnv() *Config {
-	level, formatter, output := ParseEnv()
+	level, formatter, output, err := parseEnv()
 
 	// Temporary logger.
 	logger := NewLogger(level, formatter, output)
@@ -34,29 +31,52 @@ func NewConfigFromEnv() *Config {
 		"output":    reflect.TypeOf(output).Elem().String(),
 	}).Debug("Logrus Config initialized")
 
-	return &Config{
+	c := &Config{
 		Level:     level,
 		Formatter: formatter,
 		Output:    output,
 	}
+	config.Keep(c, err)
+	return c
+}
+
+// Settings of the Logrus Provider, as loaded from the environment.
+type env struct {
+	Level     logrus.Level `env:"LEVEL" default:"info"`     // Logging level (trace, debug, info, etc).
+	Formatter string       `env:"FORMATTER" default:"json"` // Log formatter (text, text_clr or json).
+	Output    string       `env:"OUTPUT" default:"stderr"`  // Output writer (stderr or stdout).
+}
+
+// Checks the formatter and output are supported.
+func (e *env) Validate() error {
+	switch e.Formatter {
+	case "text", "text_clr", "json":
+	default:
+		return fmt.Errorf("unsupported formatter %s", e.Formatter)
+	}
+	switch e.Output {
+	case "stdout", "stderr":
+	default:
+		return fmt.Errorf("unsupported output %s", e.Output)
+	}
+	return nil
 }
 
 // Parses the environment variables.
 // This is done in a separate method, which can be used as parameter for NewLogger().
+// Unsupported values fall back to the defaults.
 func ParseEnv() (logrus.Level, logrus.Formatter, io.Writer) {
-	v := viper.New()
-	v.SetEnvPrefix("LOGRUS")
-	v.AutomaticEnv()
+	level, formatter, output, _ := parseEnv()
+	return level, formatter, output
+}
 
-	v.SetDefault("LEVEL", defaultLevel)
-	level, err := logrus.ParseLevel(v.GetString("LEVEL"))
-	if err != nil {
-		level = logrus.InfoLevel
-	}
+// Parses the environment variables like ParseEnv(), also returning the errors of the unsupported values.
+func parseEnv() (logrus.Level, logrus.Formatter, io.Writer, error) {
+	e := &env{}
+	err := config.Load("LOGRUS", e)
 
-	v.SetDefault("FORMATTER", defaultFormatter)
 	var formatter logrus.Formatter
-	switch v.GetString("FORMATTER") {
+	switch e.Formatter {
 	case "text":
 		formatter = &logrus.TextFormatter{}
 	case "text_clr":
@@ -67,9 +87,8 @@ func ParseEnv() (logrus.Level, logrus.Formatter, io.Writer) {
 		formatter = &logrus.JSONFormatter{}
 	}
 
-	v.SetDefault("OUTPUT", defaultOutput)
 	var output io.Writer
-	switch v.GetString("OUTPUT") {
+	switch e.Output {
 	case "stdout":
 		output = os.Stdout
 	case "stderr":
@@ -78,5 +97,5 @@ func ParseEnv() (logrus.Level, logrus.Formatter, io.Writer) {
 		output = os.Stderr
 	}
 
-	return level, formatter, output
+	return e.Level, formatter, output, err
 }
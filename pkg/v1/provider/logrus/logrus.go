@@ -3,6 +3,7 @@ package logrus
 import (
 	"context"
 	"fmt"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
 	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider"
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/logrus/ctxlogrus"
 	"github.com/opentracing/opentracing-go"
@@ -18,6 +19,7 @@ type Logrus struct {
 	provider.AbstractProvider
 
 	Config *Config
+	loaded logrus.Level // Level of the environment variables and configuration file when last loaded, so only changes to it are applied.
 }
 
 // Creates a Logrus Provider.
@@ -27,6 +29,11 @@ func New(config *Config) *Logrus {
 	}
 }
 
+// Returns the invalid settings of the Logrus configuration, if any (see provider.Configured).
+func (p *Logrus) ConfigError() error {
+	return config.Err(p.Config)
+}
+
 // Initializes the logrus standard logger.
 func (p *Logrus) Init() error {
 	logrus.SetLevel(p.Config.Level)
@@ -38,6 +45,30 @@ func (p *Logrus) Init() error {
 		logrus.SetOutput(p.Config.Output)
 	}
 
+	e := &env{}
+	_ = config.Reload("LOGRUS", e)
+	p.loaded = e.Level
+
+	return nil
+}
+
+// Applies a changed logging level to the logrus standard logger.
+// The level is only replaced when the environment variables or the configuration file changed it, so a level set in code is kept otherwise.
+// The formatter and output can't be changed while running.
+func (p *Logrus) Reconfigure() error {
+	e := &env{}
+	if err := config.Reload("LOGRUS", e); err != nil {
+		return err
+	}
+
+	if e.Level != p.loaded {
+		logrus.WithFields(logrus.Fields{
+			"from": logrus.GetLevel(),
+			"to":   e.Level,
+		}).Info("Logging level changed")
+		logrus.SetLevel(e.Level)
+		p.loaded = e.Level
+	}
 	return nil
 }
 
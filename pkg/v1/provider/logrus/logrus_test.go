@@ -100,6 +100,25 @@ var _ = Describe("Logrus provider", func() {
 			_ = os.Unsetenv("LOGRUS_OUTPUT")
 		})
 	})
+	Context("Reconfiguration", func() {
+		It("Applies a changed level and rejects invalid ones", func() {
+			p := New(&Config{Level: logrus.DebugLevel})
+			Expect(p.Init()).To(Succeed())
+			defer os.Unsetenv("LOGRUS_LEVEL")
+
+			// The level set in code is kept until the environment changes it.
+			Expect(p.Reconfigure()).To(Succeed())
+			Expect(logrus.GetLevel()).To(Equal(logrus.DebugLevel))
+
+			_ = os.Setenv("LOGRUS_LEVEL", "warn")
+			Expect(p.Reconfigure()).To(Succeed())
+			Expect(logrus.GetLevel()).To(Equal(logrus.WarnLevel))
+
+			_ = os.Setenv("LOGRUS_LEVEL", "hyper")
+			Expect(p.Reconfigure()).ToNot(Succeed())
+			Expect(logrus.GetLevel()).To(Equal(logrus.WarnLevel))
+		})
+	})
 	Context("Context logger", func() {
 		It("Returns a proper log entry if no context logger is set", func() {
 			ctx := context.Background()
@@ -1,31 +1,21 @@
 package migrate
 
 import (
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
 	"github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
-)
-
-const (
-	defaultPath = "scripts/json"
 )
 
 type Config struct {
-	Directory string
+	config.Result
+
+	Directory string `env:"DIRECTORY" default:"scripts/json"` // Directory containing the JSON migration scripts.
 }
 
 func NewConfigFromEnv() *Config {
-	v := viper.New()
-	v.SetEnvPrefix("MIGRATIONS")
-	v.AutomaticEnv()
-
-	v.SetDefault("DIRECTORY", defaultPath)
-	directory := v.GetString("DIRECTORY")
+	c := &Config{}
+	_ = config.Load("MIGRATIONS", c)
 
-	logrus.WithFields(logrus.Fields{
-		"directory": directory,
-	}).Debug("Migrate Config Initialized")
+	logrus.WithFields(config.Fields(c)).Debug("Migrate Config Initialized")
 
-	return &Config{
-		Directory: directory,
-	}
+	return c
 }
@@ -2,6 +2,7 @@ package migrate
 
 import (
 	"fmt"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
 	"io/ioutil"
 	"net/url"
 	"strconv"
@@ -28,6 +29,24 @@ func New(config *Config, mongodb *mongodb.MongoDB) *Migrate {
 	}
 }
 
+// Returns the invalid settings of the Migrate configuration, if any (see provider.Configured).
+func (p *Migrate) ConfigError() error {
+	return config.Err(p.Config)
+}
+
+// Migrations can only run once the MongoDB Provider is connected.
+func (s *Migrate) Dependencies() []provider.Provider {
+	return []provider.Provider{s.Mongodb}
+}
+
+// Looks up the MongoDB Provider in the Stack, if it wasn't passed to New().
+func (s *Migrate) Resolve(registry provider.Registry) error {
+	if s.Mongodb == nil && !registry.Lookup(&s.Mongodb) {
+		return fmt.Errorf("Migrate Provider requires a MongoDB Provider")
+	}
+	return nil
+}
+
 func (s *Migrate) Init() (err error) {
 	directory := s.Config.Directory
 	logrus.Infof("Run migrations under directory: %s", directory)
@@ -46,7 +65,7 @@ func (s *Migrate) Init() (err error) {
 		logrus.Errorf("Failed to get mongo config: %v", err)
 		return err
 	}
-	db, err := mongo.WithInstance(s.Mongodb.Client, mongoCfg)
+	db, err := mongo.WithInstance(s.Mongodb.Client(), mongoCfg)
 	if err != nil {
 		logrus.Errorf("Failed to WithInstance: %v", err)
 		return err
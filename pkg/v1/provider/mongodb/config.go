@@ -1,101 +1,78 @@
 package mongodb
 
 import (
+	"fmt"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
 	"github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
+	"strings"
 	"time"
 )
 
-const (
-	defaultURI               = ""
-	defaultHost              = "127.0.0.1:27017"
-	defaultParameter         = ""
-	defaultUser              = ""
-	defaultPassword          = ""
-	defaultDatabase          = "test"
-	defaultTimeout           = 20
-	defaultMaxPoolSize       = 16
-	defaultMaxConnIdleTime   = 30
-	defaultHeartbeatInterval = 15
-)
-
 // Configuration for the MongoDB Provider.
 type Config struct {
-	URI               string        // URI where to find the MongoDB server (including protocol and port).
-	Database          string        // Database name to use.
-	Timeout           time.Duration // Maximum duration to wait until the initial connection with the database is established.
-	MaxPoolSize       uint64        // Maximum number of connections.
-	MaxConnIdleTime   time.Duration // Maximum idle time before a connection is removed from the pool.
-	HeartbeatInterval time.Duration // Interval between connection checks.
+	config.Result
+
+	URI               string        `env:"URI"`                                     // URI where to find the MongoDB server (including protocol and port). Built from the HOST, USER, PASSWORD and PARAMETER settings if empty.
+	Database          string        `env:"DATABASE" default:"test" required:"true"` // Database name to use.
+	Timeout           time.Duration `env:"TIMEOUT" default:"20" min:"0"`            // Maximum duration to wait until the initial connection with the database is established.
+	MaxPoolSize       uint64        `env:"MAX_POOL_SIZE" default:"16" min:"0"`      // Maximum number of connections. Zero means no limit.
+	MaxConnIdleTime   time.Duration `env:"MAX_CONN_IDLE_TIME" default:"30" min:"0"` // Maximum idle time before a connection is removed from the pool.
+	HeartbeatInterval time.Duration `env:"HEARTBEAT_INTERVAL" default:"15" min:"1"` // Interval between connection checks.
+}
+
+// Settings used to build the URI if none is configured.
+type server struct {
+	Host      string `env:"HOST" default:"127.0.0.1:27017"` // Host (and port) of the MongoDB server.
+	Parameter string `env:"PARAMETER"`                      // Connection string options (e.g. authSource=admin).
+	User      string `env:"USER"`                           // Name of the user to authenticate as.
+	Password  string `env:"PASSWORD" secret:"true"`         // Password of the user.
 }
 
 // Initializes the configuration from environment variables.
 func NewConfigFromEnv() *Config {
-	v := viper.New()
-	v.SetEnvPrefix("MONGODB")
-	v.AutomaticEnv()
-
-	v.SetDefault("URI", defaultURI)
-	uri := v.GetString("URI")
+	c, err := loadConfig(config.Load)
+	config.Keep(c, err)
 
-	v.SetDefault("DATABASE", defaultDatabase)
-	database := v.GetString("DATABASE")
+	logrus.WithFields(config.Fields(c)).Debug("MongoDB Config initialized")
 
-	if uri == defaultURI {
-		v.SetDefault("HOST", defaultHost)
-		host := v.GetString("HOST")
-
-		v.SetDefault("PARAMETER", defaultParameter)
-		parameter := v.GetString("PARAMETER")
-
-		v.SetDefault("USER", defaultUser)
-		user := v.GetString("USER")
-
-		v.SetDefault("PASSWORD", defaultPassword)
-		password := v.GetString("PASSWORD")
-
-		if parameter != "" && parameter[0] != '?' {
-			parameter = "?" + parameter
-		}
+	return c
+}
 
-		mongoDBLogin := user
-		if password != "" && mongoDBLogin != "" {
-			mongoDBLogin = mongoDBLogin + ":" + password
-		}
-		if mongoDBLogin != "" {
-			mongoDBLogin = mongoDBLogin + "@"
-		}
+// Loads the configuration using config.Load() or config.Reload(), building the URI if none is configured.
+func loadConfig(load func(prefix string, target interface{}) error) (*Config, error) {
+	c := &Config{}
+	err := load("MONGODB", c)
 
-		uri = "mongodb://" + mongoDBLogin + host + "/" + database + parameter
+	if c.URI == "" {
+		s := &server{}
+		err = config.Merge(err, load("MONGODB", s))
+		c.URI = s.uri(c.Database)
 	}
+	return c, err
+}
 
-	v.SetDefault("TIMEOUT", defaultTimeout)
-	timeout := v.GetDuration("TIMEOUT") * time.Second
-
-	v.SetDefault("MAX_POOL_SIZE", defaultMaxPoolSize)
-	maxPoolSize := v.GetUint64("MAX_POOL_SIZE")
-
-	v.SetDefault("MAX_CONN_IDLE_TIME", defaultMaxConnIdleTime)
-	maxConnIdleTime := v.GetDuration("MAX_CONN_IDLE_TIME") * time.Second
-
-	v.SetDefault("HEARTBEAT_INTERVAL", defaultHeartbeatInterval)
-	heartbeatInterval := v.GetDuration("HEARTBEAT_INTERVAL") * time.Second
+// Checks the URI uses one of the MongoDB schemes.
+func (c *Config) Validate() error {
+	if c.URI != "" && !strings.HasPrefix(c.URI, "mongodb://") && !strings.HasPrefix(c.URI, "mongodb+srv://") {
+		return fmt.Errorf("URI %s should start with mongodb:// or mongodb+srv://", config.Redact(c.URI))
+	}
+	return nil
+}
 
-	logrus.WithFields(logrus.Fields{
-		"uri":                uri,
-		"database":           database,
-		"timeout":            timeout,
-		"max_pool_size":      maxPoolSize,
-		"max_conn_idle_time": maxConnIdleTime,
-		"heartbeat_interval": heartbeatInterval,
-	}).Debug("MongoDB Config initialized")
+// Builds the URI of the server for the given database.
+func (s *server) uri(database string) string {
+	parameter := s.Parameter
+	if parameter != "" && parameter[0] != '?' {
+		parameter = "?" + parameter
+	}
 
-	return &Config{
-		URI:               uri,
-		Database:          database,
-		Timeout:           timeout,
-		MaxPoolSize:       maxPoolSize,
-		MaxConnIdleTime:   maxConnIdleTime,
-		HeartbeatInterval: heartbeatInterval,
+	login := s.User
+	if s.Password != "" && login != "" {
+		login = login + ":" + s.Password
 	}
+	if login != "" {
+		login = login + "@"
+	}
+
+	return "mongodb://" + login + s.Host + "/" + database + parameter
 }
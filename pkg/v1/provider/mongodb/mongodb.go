@@ -2,12 +2,14 @@ package mongodb
 
 import (
 	"context"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
 	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider"
 	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/app"
 	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/probes"
 	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"sync"
 	"time"
 )
 
@@ -20,13 +22,15 @@ type MongoDB struct {
 	probesProvider *probes.Probes
 	appProvider    *app.App
 
-	Client   *mongo.Client
-	Database *mongo.Database
+	mu       sync.RWMutex
+	client   *mongo.Client   // Replaced when the URI changes (see Reconfigure()).
+	database *mongo.Database // Replaced along with the client.
+	loaded   string          // URI of the environment variables, secret files and configuration file when last loaded, so only changes to it are applied.
 }
 
 // Creates a MongoDB Provider.
 // Uses the ProbesProvider to add a liveness probe.
-// Uses the AppProvider to send the application name to the MongoDB client.
+// Uses the AppProvider to send the application name and instance to the MongoDB server (as the appName, see app.Identity).
 func New(config *Config, probesProvider *probes.Probes, appProvider *app.App) *MongoDB {
 	return &MongoDB{
 		Config:         config,
@@ -35,23 +39,105 @@ func New(config *Config, probesProvider *probes.Probes, appProvider *app.App) *M
 	}
 }
 
+// Returns the invalid settings of the MongoDB configuration, if any (see provider.Configured).
+func (p *MongoDB) ConfigError() error {
+	return config.Err(p.Config)
+}
+
+// MongoDB depends on the (optional) Probes and App Providers.
+func (p *MongoDB) Dependencies() []provider.Provider {
+	return []provider.Provider{p.probesProvider, p.appProvider}
+}
+
+// Looks up the optional Probes and App Providers in the Stack, if they weren't passed to New().
+func (p *MongoDB) Resolve(registry provider.Registry) error {
+	if p.probesProvider == nil {
+		registry.Lookup(&p.probesProvider)
+	}
+	if p.appProvider == nil {
+		registry.Lookup(&p.appProvider)
+	}
+	return nil
+}
+
 // Creates a MongoDB Client, connects to the database server and selects the configured database to be used.
 func (p *MongoDB) Init() error {
+	return p.InitContext(context.Background())
+}
+
+// Connects to the database server (see Init()), giving up once the context is done or the configured timeout has passed.
+func (p *MongoDB) InitContext(ctx context.Context) error {
+	client, err := p.connect(ctx, p.Config.URI)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.client, p.database = client, client.Database(p.Config.Database)
+	p.mu.Unlock()
+	if c, _ := loadConfig(config.Reload); c != nil {
+		p.loaded = c.URI
+	}
+
+	// Add live probes if possible.
+	if p.probesProvider != nil {
+		p.probesProvider.AddLivenessProbes(p.livenessProbe)
+	}
+
+	return nil
+}
+
+// Reconnects to the database server when its URI changed (e.g. when the password was rotated, see MONGODB_PASSWORD_FILE).
+// The new connection is established before the previous one is closed, so the previous credentials are used until the new ones are known to work.
+// The URI is only replaced when the environment variables, secret files or configuration file changed it, so a URI set in code is kept otherwise.
+// Other settings only apply after a restart. Callers should get the client with Client() or Database() for each use, rather than keep it.
+func (p *MongoDB) Reconfigure() error {
+	c, err := loadConfig(config.Reload)
+	if err != nil {
+		return err
+	}
+	if c.URI == p.loaded {
+		return nil
+	}
+
+	client, err := p.connect(context.Background(), c.URI)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	previous := p.client
+	p.Config.URI, p.client, p.database = c.URI, client, client.Database(p.Config.Database)
+	p.mu.Unlock()
+	p.loaded = c.URI
+	logrus.WithField("address", config.Redact(c.URI)).Info("Reconnected to MongoDB server")
+
+	// Operations still using the previous client are given the configured timeout to complete.
+	ctx, cancel := context.WithTimeout(context.Background(), p.Config.Timeout)
+	defer cancel()
+	if err := previous.Disconnect(ctx); err != nil {
+		logrus.WithError(err).Info("MongoDB disconnecting the previous client failed")
+	}
+	return nil
+}
+
+// Creates a client connected to the database server at the given URI, and checks the connection by pinging.
+func (p *MongoDB) connect(ctx context.Context, uri string) (*mongo.Client, error) {
 	opts := options.Client()
-	opts.ApplyURI(p.Config.URI)
+	opts.ApplyURI(uri)
 	opts.SetConnectTimeout(p.Config.Timeout)
 	opts.SetHeartbeatInterval(p.Config.HeartbeatInterval)
 	opts.SetMaxPoolSize(p.Config.MaxPoolSize)
 	opts.SetMaxConnIdleTime(p.Config.MaxConnIdleTime)
 
 	if p.appProvider != nil {
-		opts.SetAppName(p.appProvider.Name())
+		opts.SetAppName(p.appProvider.Identity().String())
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), p.Config.Timeout)
+	ctx, cancel := context.WithTimeout(ctx, p.Config.Timeout)
 	defer cancel()
 
-	logEntry := logrus.WithField("address", p.Config.URI).WithField("time_out", p.Config.Timeout.String())
+	logEntry := logrus.WithField("address", config.Redact(uri)).WithField("time_out", p.Config.Timeout.String())
 
 	logEntry.Debug("Connecting to MongoDB server...")
 
@@ -59,39 +145,37 @@ func (p *MongoDB) Init() error {
 	client, err := mongo.NewClient(opts)
 	if err != nil {
 		logEntry.WithError(err).Error("MongoDB client creation failed")
-		return err
+		return nil, err
 	}
 
 	err = client.Connect(ctx)
 	if err != nil {
 		logEntry.WithError(err).Error("MongoDB connection failed")
-		return err
+		return nil, err
 	}
 
 	// Check connection by pinging.
 	err = client.Ping(ctx, nil)
 	if err != nil {
 		logEntry.WithError(err).Error("MongoDB ping failed")
-		return err
-	}
-
-	p.Client = client
-	p.Database = client.Database(p.Config.Database)
-
-	// Add live probes if possible.
-	if p.probesProvider != nil {
-		p.probesProvider.AddLivenessProbes(p.livenessProbe)
+		_ = client.Disconnect(context.Background())
+		return nil, err
 	}
 
-	return nil
+	return client, nil
 }
 
 // Close to connection with the MongoDB server.
 func (p *MongoDB) Close() error {
-	ctx, cancel := context.WithTimeout(context.Background(), p.Config.Timeout)
+	return p.CloseContext(context.Background())
+}
+
+// Closes the connection with the MongoDB server, giving up once the context is done or the configured timeout has passed.
+func (p *MongoDB) CloseContext(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, p.Config.Timeout)
 	defer cancel()
 
-	err := p.Client.Disconnect(ctx)
+	err := p.Client().Disconnect(ctx)
 	if err != nil {
 		logrus.WithError(err).Info("MongoDB disconnecting failed")
 		return err
@@ -104,7 +188,7 @@ func (p *MongoDB) livenessProbe() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	err := p.Client.Ping(ctx, nil)
+	err := p.Client().Ping(ctx, nil)
 	if err != nil {
 		logrus.WithError(err).Error("MongoDB liveness probe failed")
 		return err
@@ -113,3 +197,27 @@ func (p *MongoDB) livenessProbe() error {
 	logrus.Debug("MongoDB liveness probe succeeded")
 	return nil
 }
+
+// Returns the client connected to the database server.
+// The client is replaced, and the previous one disconnected, when the URI changes (see Reconfigure()), so it shouldn't be kept.
+func (p *MongoDB) Client() *mongo.Client {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.client
+}
+
+// Returns the configured database, of the current client (see Client()).
+func (p *MongoDB) Database() *mongo.Database {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.database
+}
+
+func (p *MongoDB) uri() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.Config.URI
+}
@@ -54,31 +54,31 @@ func NewMongoRepository(mongoProvider *MongoDB) IMongoRepository {
 }
 
 func (m MongoRepository) MongoClient() *mongo.Client {
-	return m.mongoProvider.Client
+	return m.mongoProvider.Client()
 }
 
 func (m MongoRepository) MongoDatabase(ctx context.Context) *mongo.Database {
 	// multi-tenant support
 	if tenantID, ok := tenant.FromTenantInterceptorContext(ctx); ok && m.databaseNameGetter != nil {
-		return m.mongoProvider.Client.Database(m.databaseNameGetter(tenantID))
+		return m.mongoProvider.Client().Database(m.databaseNameGetter(tenantID))
 	}
 
 	// MONGODB_DATABASE set
 	if len(os.Getenv("MONGODB_DATABASE")) > 0 {
-		return m.mongoProvider.Database
+		return m.mongoProvider.Database()
 	}
 
 	// MONGODB_URI  contains database_name
-	uri, err := connstring.Parse(m.mongoProvider.Config.URI)
+	uri, err := connstring.Parse(m.mongoProvider.uri())
 	if err == nil && len(uri.Database) > 0 {
-		return m.mongoProvider.Client.Database(uri.Database)
+		return m.mongoProvider.Client().Database(uri.Database)
 	}
 
 	panic(status.Error(codes.Internal, "No database specified"))
 }
 
 func (m MongoRepository) RunTransaction(ctx context.Context, txnFn func(mongo.SessionContext) error) error {
-	return m.mongoProvider.Client.UseSessionWithOptions(ctx,
+	return m.mongoProvider.Client().UseSessionWithOptions(ctx,
 		options.Session().SetDefaultReadPreference(readpref.Primary()), func(sessionContext mongo.SessionContext) error {
 			return m.runTransactionWithRetry(sessionContext, txnFn)
 		})
@@ -1,55 +1,40 @@
 package nats
 
 import (
-	"github.com/nats-io/nats.go"
+	"fmt"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
 	"github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
+	"net/url"
+	"strings"
 	"time"
 )
 
-const (
-	defaultURI     = "nats://127.0.0.1:4222"
-	defaultTimeout = 20
-	defaultEncoder = nats.JSON_ENCODER
-)
-
 // Configuration for the NATS Provider.
 type Config struct {
-	Enabled bool          // Whether or not a connection should be made and events should be emitted.
-	URI     string        // URI where to find the NATS service.
-	Timeout time.Duration // Maximum duration to wait until connection with the NATS service is established.
-	Encoder string        // NATS Encoder (see go-nats/enc.go).
+	config.Result
+
+	Enabled bool          `env:"ENABLED" default:"true"`                              // Whether or not a connection should be made and events should be emitted.
+	URI     string        `env:"URI" default:"nats://127.0.0.1:4222" required:"true"` // URI where to find the NATS service. Multiple servers can be separated by commas.
+	Timeout time.Duration `env:"TIMEOUT" default:"20" min:"0"`                        // Maximum duration to wait until connection with the NATS service is established.
+	Encoder string        `env:"ENCODER" default:"json"`                              // NATS Encoder (see go-nats/enc.go).
 }
 
 // Initializes the configuration from environment variables.
 func NewConfigFromEnv() *Config {
-	v := viper.New()
-	v.SetEnvPrefix("NATS")
-	v.AutomaticEnv()
-
-	v.SetDefault("ENABLED", true)
-	enabled := v.GetBool("ENABLED")
+	c := &Config{}
+	_ = config.Load("NATS", c)
 
-	v.SetDefault("URI", defaultURI)
-	uri := v.GetString("URI")
+	logrus.WithFields(config.Fields(c)).Debug("NATS Config initialized")
 
-	v.SetDefault("TIMEOUT", defaultTimeout)
-	timeout := v.GetDuration("TIMEOUT") * time.Second
-
-	v.SetDefault("ENCODER", defaultEncoder)
-	encoder := v.GetString("ENCODER")
-
-	logrus.WithFields(logrus.Fields{
-		"enabled": enabled,
-		"uri":     uri,
-		"timeout": timeout,
-		"encoder": encoder,
-	}).Debug("NATS Config initialized")
+	return c
+}
 
-	return &Config{
-		Enabled: enabled,
-		URI:     uri,
-		Timeout: timeout,
-		Encoder: encoder,
+// Checks the URI of every server can be parsed.
+func (c *Config) Validate() error {
+	for _, server := range strings.Split(c.URI, ",") {
+		if _, err := url.Parse(strings.TrimSpace(server)); err != nil {
+			return fmt.Errorf("invalid URI %s", config.Redact(server))
+		}
 	}
+	return nil
 }
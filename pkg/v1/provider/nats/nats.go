@@ -3,7 +3,9 @@ package nats
 import (
 	"context"
 	"fmt"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
 	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/app"
 	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/probes"
 	"github.com/nats-io/nats.go"
 	"github.com/opentracing/opentracing-go"
@@ -18,6 +20,7 @@ type Nats struct {
 
 	Config         *Config
 	probesProvider *probes.Probes
+	appProvider    *app.App
 
 	Conn *nats.EncodedConn
 }
@@ -31,6 +34,28 @@ func New(config *Config, probesProvider *probes.Probes) *Nats {
 	}
 }
 
+// Returns the invalid settings of the NATS configuration, if any (see provider.Configured).
+func (p *Nats) ConfigError() error {
+	return config.Err(p.Config)
+}
+
+// NATS depends on the (optional) Probes and App Providers.
+func (p *Nats) Dependencies() []provider.Provider {
+	return []provider.Provider{p.probesProvider, p.appProvider}
+}
+
+// Looks up the optional Probes Provider in the Stack if it wasn't passed to New(),
+// and the optional App Provider, used to name the connection after the instance (see app.Identity).
+func (p *Nats) Resolve(registry provider.Registry) error {
+	if p.probesProvider == nil {
+		registry.Lookup(&p.probesProvider)
+	}
+	if p.appProvider == nil {
+		registry.Lookup(&p.appProvider)
+	}
+	return nil
+}
+
 // Creates an encoded connection with the NATS service.
 func (p *Nats) Init() error {
 	if !p.Config.Enabled {
@@ -51,6 +76,9 @@ func (p *Nats) Init() error {
 			logrus.WithError(err).Error("NATS was disconnected")
 		}),
 	}
+	if p.appProvider != nil {
+		opts = append(opts, nats.Name(p.appProvider.Identity().String()))
+	}
 
 	logEntry := logrus.WithField("address", p.Config.URI)
 	logEntry.Debug("Connecting to NATS service...")
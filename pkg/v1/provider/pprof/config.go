@@ -1,46 +1,28 @@
 package pprof
 
 import (
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/httpserver"
 	"github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
-)
-
-const (
-	defaultPort     = 9999
-	defaultEndpoint = "/debug/pprof"
 )
 
 // Configuration for the PProf Provider.
 type Config struct {
-	Enabled  bool   // Whether or not the the HTTP service should be running.
-	Port     int    // Port on which to start the HTTP service.
-	Endpoint string // Endpoint on which to expose the profiler.
+	config.Result
+
+	Enabled  bool               `env:"ENABLED" default:"true"`                  // Whether or not the the HTTP service should be running.
+	Port     int                `env:"PORT" default:"9999" min:"0" max:"65535"` // Port on which to start the HTTP service.
+	Server   *httpserver.Config // Settings of the HTTP server (timeouts, TLS...). Defaults are used if nil.
+	Endpoint string             `env:"ENDPOINT" default:"/debug/pprof"` // Endpoint on which to expose the profiler.
 }
 
-// NewConfigFromEnv ...
+// Initializes the configuration from environment variables.
 func NewConfigFromEnv() *Config {
-	v := viper.New()
-	v.SetEnvPrefix("PPROF")
-	v.AutomaticEnv()
-
-	v.SetDefault("ENABLED", true)
-	enabled := v.GetBool("ENABLED")
-
-	v.SetDefault("PORT", defaultPort)
-	port := v.GetInt("PORT")
-
-	v.SetDefault("ENDPOINT", defaultEndpoint)
-	endpoint := v.GetString("ENDPOINT")
+	c := &Config{}
+	_ = config.Load("PPROF", c)
+	c.Server = httpserver.NewConfigFromEnv("PPROF")
 
-	logrus.WithFields(logrus.Fields{
-		"enabled":  enabled,
-		"port":     port,
-		"endpoint": endpoint,
-	}).Debug("PProf Config initialized")
+	logrus.WithFields(config.Fields(c)).Debug("PProf Config initialized")
 
-	return &Config{
-		Enabled:  enabled,
-		Port:     port,
-		Endpoint: endpoint,
-	}
+	return c
 }
@@ -1,12 +1,10 @@
 package pprof
 
 import (
-	"context"
-	"fmt"
-	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/httpserver"
 	"net/http"
 	"net/http/pprof"
-	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -14,11 +12,9 @@ import (
 // PProf Provider.
 // Provides profiling data to be used by a Google PProf visualization/analysis tool.
 type PProf struct {
-	provider.AbstractRunProvider
+	httpserver.Server
 
 	Config *Config
-
-	srv *http.Server
 }
 
 // Creates a PProf Provider.
@@ -28,6 +24,11 @@ func New(config *Config) *PProf {
 	}
 }
 
+// Returns the invalid settings of the PProf configuration, if any (see provider.Configured).
+func (p *PProf) ConfigError() error {
+	return config.Err(p.Config)
+}
+
 // Creates an HTTP service on the configured port and endpoint, where profiling data is published.
 func (p *PProf) Run() error {
 	if !p.Config.Enabled {
@@ -35,40 +36,30 @@ func (p *PProf) Run() error {
 		return nil
 	}
 
-	addr := fmt.Sprintf(":%d", p.Config.Port)
-
 	logEntry := logrus.WithFields(logrus.Fields{
-		"addr":     addr,
+		"port":     p.Config.Port,
 		"endpoint": p.Config.Endpoint,
 	})
 
 	mux := http.NewServeMux()
-	mux.HandleFunc(p.Config.Endpoint+"/", pprof.Index)
-	mux.HandleFunc(p.Config.Endpoint+"/cmdline", pprof.Cmdline)
-	mux.HandleFunc(p.Config.Endpoint+"/profile", pprof.Profile)
-	mux.HandleFunc(p.Config.Endpoint+"/symbol", pprof.Symbol)
-	mux.HandleFunc(p.Config.Endpoint+"/trace", pprof.Trace)
-
-	p.srv = &http.Server{Addr: addr, Handler: mux}
-	p.SetRunning(true)
-
-	logEntry.Info("PProf Provider launched")
-	if err := p.srv.ListenAndServe(); err != http.ErrServerClosed {
-		logEntry.WithError(err).Error("PProf Provider launch failed")
-	}
+	p.Mount(mux)
+	return p.Serve("PProf Provider", p.Config.Port, p.Config.Server, mux, logEntry)
+}
 
+// Resets the HTTP service after it stopped, so the Stack can restart it (see provider.Restartable).
+func (p *PProf) Restart() error {
+	p.Reset()
 	return nil
 }
 
-func (p *PProf) Close() error {
-	if !p.Config.Enabled || p.srv == nil {
-		return p.AbstractRunProvider.Close()
-	}
-
-	ctx, _ := context.WithTimeout(context.Background(), 1*time.Millisecond)
-	if err := p.srv.Shutdown(ctx); err != nil {
-		logrus.WithError(err).Error("Error while closing PProf server")
+// Registers the profiling handlers on the mux, either its own or the one of the Admin Provider.
+func (p *PProf) Mount(mux *http.ServeMux) {
+	if !p.Config.Enabled {
+		return
 	}
-
-	return p.AbstractRunProvider.Close()
+	mux.HandleFunc(p.Config.Endpoint+"/", pprof.Index)
+	mux.HandleFunc(p.Config.Endpoint+"/cmdline", pprof.Cmdline)
+	mux.HandleFunc(p.Config.Endpoint+"/profile", pprof.Profile)
+	mux.HandleFunc(p.Config.Endpoint+"/symbol", pprof.Symbol)
+	mux.HandleFunc(p.Config.Endpoint+"/trace", pprof.Trace)
 }
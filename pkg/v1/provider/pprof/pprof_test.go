@@ -1,12 +1,14 @@
 package pprof
 
 import (
+	"context"
 	"fmt"
 	"github.azc.ext.hp.com/hp-business-platform/lib-core-go/pkg/v1/test"
 	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/sirupsen/logrus"
+	"net"
 	"net/http"
 	"testing"
 	"time"
@@ -23,8 +25,8 @@ var _ = Describe("Pprof provider", func() {
 		var p *PProf
 		By("Creating and initializing the provider", func() {
 			p = New(&Config{
-				Port:     defaultPort,
-				Endpoint: defaultEndpoint,
+				Port:     0,
+				Endpoint: "/debug/pprof",
 				Enabled:  true,
 			})
 			err := p.Init()
@@ -35,15 +37,38 @@ var _ = Describe("Pprof provider", func() {
 				err := p.Run()
 				Expect(err).NotTo(HaveOccurred())
 			}()
-			err := provider.WaitForRunningProvider(p, 2*time.Second)
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			err := provider.WaitReady(ctx, p)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(p.IsRunning()).To(BeTrue())
 		})
 		By("Getting the profiling data", func() {
-			res, err := http.Get(fmt.Sprintf("http://localhost:%d%s", defaultPort, defaultEndpoint))
+			res, err := http.Get(fmt.Sprintf("http://localhost:%d%s", p.Addr().(*net.TCPAddr).Port, "/debug/pprof"))
 			Expect(err).NotTo(HaveOccurred())
 			Expect(res).NotTo(BeNil())
 			Expect(res.StatusCode).To(Equal(200))
 		})
 	})
+	It("Runs again once restarted", func() {
+		p := New(&Config{Port: 0, Endpoint: "/debug/pprof", Enabled: true})
+		Expect(p.Init()).To(Succeed())
+		for i := 0; i < 2; i++ {
+			if i > 0 {
+				Expect(p.Restart()).To(Succeed())
+			}
+			done := make(chan error)
+			go func() {
+				done <- p.Run()
+			}()
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			Expect(provider.WaitReady(ctx, p)).To(Succeed())
+			res, err := http.Get(fmt.Sprintf("http://localhost:%d%s", p.Addr().(*net.TCPAddr).Port, "/debug/pprof/"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.StatusCode).To(Equal(200))
+			Expect(p.Close()).To(Succeed())
+			Eventually(done).Should(Receive(BeNil()))
+		}
+	})
 })
@@ -1,53 +1,29 @@
 package probes
 
 import (
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/httpserver"
 	"github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
-)
-
-const (
-	defaultPort              = 8000
-	defaultLivenessEndpoint  = "/healthz"
-	defaultReadinessEndpoint = "/ready"
 )
 
 // Configuration for the Probes Provider.
 type Config struct {
-	Enabled           bool   // Whether or not the the HTTP service should be running.
-	Port              int    // Port on which to start the HTTP service.
-	LivenessEndpoint  string // Endpoint on which to expose the liveness status.
-	ReadinessEndpoint string // Endpoint on which to expose the readiness status.
+	config.Result
+
+	Enabled           bool               `env:"ENABLED" default:"true"`                  // Whether or not the the HTTP service should be running.
+	Port              int                `env:"PORT" default:"8000" min:"0" max:"65535"` // Port on which to start the HTTP service.
+	Server            *httpserver.Config // Settings of the HTTP server (timeouts, TLS...). Defaults are used if nil.
+	LivenessEndpoint  string             `env:"LIVENESS_ENDPOINT" default:"/healthz"` // Endpoint on which to expose the liveness status.
+	ReadinessEndpoint string             `env:"READINESS_ENDPOINT" default:"/ready"`  // Endpoint on which to expose the readiness status.
 }
 
 // Initializes the configuration from environment variables.
 func NewConfigFromEnv() *Config {
-	v := viper.New()
-	v.SetEnvPrefix("PROBES")
-	v.AutomaticEnv()
-
-	v.SetDefault("ENABLED", true)
-	enabled := v.GetBool("ENABLED")
-
-	v.SetDefault("PORT", defaultPort)
-	port := v.GetInt("PORT")
-
-	v.SetDefault("LIVENESS_ENDPOINT", defaultLivenessEndpoint)
-	livenessEndpoint := v.GetString("LIVENESS_ENDPOINT")
-
-	v.SetDefault("READINESS_ENDPOINT", defaultReadinessEndpoint)
-	readinessEndpoint := v.GetString("READINESS_ENDPOINT")
+	c := &Config{}
+	_ = config.Load("PROBES", c)
+	c.Server = httpserver.NewConfigFromEnv("PROBES")
 
-	logrus.WithFields(logrus.Fields{
-		"enabled":            enabled,
-		"port":               port,
-		"liveness_endpoint":  livenessEndpoint,
-		"readiness_endpoint": readinessEndpoint,
-	}).Debug("Probes Config initialized")
+	logrus.WithFields(config.Fields(c)).Debug("Probes Config initialized")
 
-	return &Config{
-		Enabled:           enabled,
-		Port:              port,
-		LivenessEndpoint:  livenessEndpoint,
-		ReadinessEndpoint: readinessEndpoint,
-	}
+	return c
 }
@@ -1,17 +1,21 @@
 package probes
 
 import (
-	"context"
+	"errors"
 	"fmt"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
 	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider"
 	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/app"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/httpserver"
 	"net/http"
 	"net/http/httputil"
-	"time"
+	"sync/atomic"
 
 	"github.com/sirupsen/logrus"
 )
 
+var errShuttingDown = errors.New("shutting down")
+
 // Probe function. Called by the probe handlers to determine the status of the application.
 type ProbeFunc func() error
 
@@ -26,15 +30,14 @@ type ProbeFunc func() error
 // Readiness probes are used to describe if the application is accepting messages.
 // If these display errors, Kubernetes/Istio will remove the Pod from the load-balancers.
 type Probes struct {
-	provider.AbstractRunProvider
+	httpserver.Server
 
 	Config      *Config
 	appProvider *app.App
 
 	livenessProbes  []ProbeFunc
 	readinessProbes []ProbeFunc
-
-	srv *http.Server
+	shuttingDown    int32 // Set (atomically) once the shutdown sequence has started, making the readiness probes fail.
 }
 
 // Creates a Probes Provider.
@@ -45,6 +48,24 @@ func New(config *Config, appProvider *app.App) *Probes {
 	}
 }
 
+// Returns the invalid settings of the Probes configuration, if any (see provider.Configured).
+func (p *Probes) ConfigError() error {
+	return config.Err(p.Config)
+}
+
+// Probes depends on the App Provider for the base path.
+func (p *Probes) Dependencies() []provider.Provider {
+	return []provider.Provider{p.appProvider}
+}
+
+// Looks up the App Provider in the Stack, if it wasn't passed to New().
+func (p *Probes) Resolve(registry provider.Registry) error {
+	if p.appProvider == nil && !registry.Lookup(&p.appProvider) {
+		return fmt.Errorf("Probes Provider requires an App Provider")
+	}
+	return nil
+}
+
 // Creates an HTTP service on the configured port and endpoints, where the statuses are published.
 func (p *Probes) Run() error {
 	if !p.Config.Enabled {
@@ -52,43 +73,36 @@ func (p *Probes) Run() error {
 		return nil
 	}
 
-	addr := fmt.Sprintf(":%d", p.Config.Port)
 	livenessEndpoint := p.appProvider.ParseEndpoint(p.Config.LivenessEndpoint)
 	readinessEndpoint := p.appProvider.ParseEndpoint(p.Config.ReadinessEndpoint)
 
 	logEntry := logrus.WithFields(logrus.Fields{
-		"addr":               addr,
+		"port":               p.Config.Port,
 		"liveness_endpoint":  livenessEndpoint,
 		"readiness_endpoint": readinessEndpoint,
 	})
 
 	mux := http.NewServeMux()
-	mux.HandleFunc(livenessEndpoint, p.livenessHandler)
-	mux.HandleFunc(readinessEndpoint, p.readinessHandler)
-
-	p.srv = &http.Server{Addr: addr, Handler: mux}
-	p.SetRunning(true)
-
-	logEntry.Info("Probes Provider Launched")
-	if err := p.srv.ListenAndServe(); err != http.ErrServerClosed {
-		logEntry.WithError(err).Error("Probes Provider launch failed")
-		return err
-	}
-
-	return nil
+	p.Mount(mux)
+	return p.Serve("Probes Provider", p.Config.Port, p.Config.Server, mux, logEntry)
 }
 
-func (p *Probes) Close() error {
-	if !p.Config.Enabled || p.srv == nil {
-		return p.AbstractRunProvider.Close()
+// Registers the liveness and readiness handlers on the mux, either its own or the one of the Admin Provider.
+func (p *Probes) Mount(mux *http.ServeMux) {
+	if !p.Config.Enabled {
+		return
 	}
+	mux.HandleFunc(p.appProvider.ParseEndpoint(p.Config.LivenessEndpoint), p.livenessHandler)
+	mux.HandleFunc(p.appProvider.ParseEndpoint(p.Config.ReadinessEndpoint), p.readinessHandler)
+}
 
-	ctx, _ := context.WithTimeout(context.Background(), 1*time.Millisecond)
-	if err := p.srv.Shutdown(ctx); err != nil {
-		logrus.WithError(err).Error("Error while closing Probes server")
+// Makes the readiness probes fail as soon as the shutdown sequence starts, so Kubernetes stops sending traffic.
+func (p *Probes) OnShutdown(phase provider.ShutdownPhase) {
+	p.Server.OnShutdown(phase)
+	if phase == provider.ShutdownPhaseDrain {
+		atomic.StoreInt32(&p.shuttingDown, 1)
+		logrus.Info("Probes Provider readiness failing due to shutdown")
 	}
-
-	return p.AbstractRunProvider.Close()
 }
 
 // This handler will check each liveness probe for errors.
@@ -96,14 +110,12 @@ func (p *Probes) Close() error {
 func (p *Probes) livenessHandler(res http.ResponseWriter, req *http.Request) {
 	reqDump, _ := httputil.DumpRequest(req, false)
 	logrus.WithField("req", string(reqDump)).Debug("Handling liveness request")
-	for _, probe := range p.livenessProbes {
-		if err := probe(); err != nil {
-			res.WriteHeader(http.StatusServiceUnavailable)
-			if _, err := res.Write([]byte(err.Error())); err != nil {
-				logrus.WithError(err).Warnf("Error while writing liveness data")
-			}
-			return
+	if err := p.Live(); err != nil {
+		res.WriteHeader(http.StatusServiceUnavailable)
+		if _, err := res.Write([]byte(err.Error())); err != nil {
+			logrus.WithError(err).Warnf("Error while writing liveness data")
 		}
+		return
 	}
 	res.WriteHeader(http.StatusOK)
 }
@@ -113,16 +125,38 @@ func (p *Probes) livenessHandler(res http.ResponseWriter, req *http.Request) {
 func (p *Probes) readinessHandler(res http.ResponseWriter, req *http.Request) {
 	reqDump, _ := httputil.DumpRequest(req, false)
 	logrus.WithField("req", string(reqDump)).Debug("Handling readiness request")
+	if err := p.Ready(); err != nil {
+		res.WriteHeader(http.StatusServiceUnavailable)
+		if _, err := res.Write([]byte(err.Error())); err != nil {
+			logrus.WithError(err).Warnf("Error while writing readiness data")
+		}
+		return
+	}
+	res.WriteHeader(http.StatusOK)
+}
+
+// Checks each liveness probe, returning the first error.
+func (p *Probes) Live() error {
+	for _, probe := range p.livenessProbes {
+		if err := probe(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Checks each readiness probe, returning the first error. Fails once the shutdown sequence has started.
+// Also used by other providers reporting the status of the application (e.g. the GRPC health service).
+func (p *Probes) Ready() error {
+	if atomic.LoadInt32(&p.shuttingDown) == 1 {
+		return errShuttingDown
+	}
 	for _, probe := range p.readinessProbes {
 		if err := probe(); err != nil {
-			res.WriteHeader(http.StatusServiceUnavailable)
-			if _, err := res.Write([]byte(err.Error())); err != nil {
-				logrus.WithError(err).Warnf("Error while writing readiness data")
-			}
-			return
+			return err
 		}
 	}
-	res.WriteHeader(http.StatusOK)
+	return nil
 }
 
 // Allows adding extra liveness probes to the handler.
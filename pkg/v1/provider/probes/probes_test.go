@@ -1,6 +1,7 @@
 package probes
 
 import (
+	"context"
 	"fmt"
 	"github.azc.ext.hp.com/hp-business-platform/lib-core-go/pkg/v1/test"
 	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider"
@@ -8,6 +9,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/sirupsen/logrus"
+	"net"
 	"net/http"
 	"testing"
 	"time"
@@ -20,7 +22,7 @@ func TestProbes(t *testing.T) {
 
 var _ = Describe("Probes provider", func() {
 
-	port := 8080
+	var port int
 	livenessEndpoint := "/health"
 	readinessEndpoint := "/ready"
 
@@ -32,7 +34,7 @@ var _ = Describe("Probes provider", func() {
 			By("Creating and initializing the provider", func() {
 				p = New(&Config{
 					Enabled:           true,
-					Port:              port,
+					Port:              0,
 					LivenessEndpoint:  livenessEndpoint,
 					ReadinessEndpoint: readinessEndpoint,
 				}, app.New(app.NewConfigFromEnv()))
@@ -44,9 +46,12 @@ var _ = Describe("Probes provider", func() {
 					err := p.Run()
 					Expect(err).NotTo(HaveOccurred())
 				}()
-				err := provider.WaitForRunningProvider(p, 2*time.Second)
+				ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+				defer cancel()
+				err := provider.WaitReady(ctx, p)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(p.IsRunning()).To(BeTrue())
+				port = p.Addr().(*net.TCPAddr).Port
 			})
 		})
 
@@ -89,6 +94,25 @@ var _ = Describe("Probes provider", func() {
 				})
 			})
 		})
+
+		Context("The shutdown sequence starts", func() {
+			It("Returns an error response on the readiness endpoint only", func() {
+				p.livenessProbes = nil
+				p.readinessProbes = nil
+				p.OnShutdown(provider.ShutdownPhaseDrain)
+
+				By("Calling the liveness endpoint", func() {
+					res, err := http.Get(fmt.Sprintf("http://localhost:%d%s", port, livenessEndpoint))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(res.StatusCode).To(Equal(200))
+				})
+				By("Calling the readiness endpoint", func() {
+					res, err := http.Get(fmt.Sprintf("http://localhost:%d%s", port, readinessEndpoint))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(res.StatusCode).To(Equal(503))
+				})
+			})
+		})
 	})
 })
 
@@ -1,46 +1,28 @@
 package prometheus
 
 import (
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/httpserver"
 	"github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
-)
-
-const (
-	defaultPort     = 9090
-	defaultEndpoint = "/metrics"
 )
 
 // Configuration for the Prometheus Provider.
 type Config struct {
-	Enabled  bool   // Whether or not the the HTTP service should be running.
-	Port     int    // Port on which to start the HTTP service.
-	Endpoint string // Endpoint on which to expose the metrics.
+	config.Result
+
+	Enabled  bool               `env:"ENABLED" default:"true"`                  // Whether or not the the HTTP service should be running.
+	Port     int                `env:"PORT" default:"9090" min:"0" max:"65535"` // Port on which to start the HTTP service.
+	Server   *httpserver.Config // Settings of the HTTP server (timeouts, TLS...). Defaults are used if nil.
+	Endpoint string             `env:"ENDPOINT" default:"/metrics"` // Endpoint on which to expose the metrics.
 }
 
 // Initializes the configuration from environment variables.
 func NewConfigFromEnv() *Config {
-	v := viper.New()
-	v.SetEnvPrefix("PROMETHEUS")
-	v.AutomaticEnv()
-
-	v.SetDefault("ENABLED", true)
-	enabled := v.GetBool("ENABLED")
-
-	v.SetDefault("PORT", defaultPort)
-	port := v.GetInt("PORT")
-
-	v.SetDefault("ENDPOINT", defaultEndpoint)
-	endpoint := v.GetString("ENDPOINT")
+	c := &Config{}
+	_ = config.Load("PROMETHEUS", c)
+	c.Server = httpserver.NewConfigFromEnv("PROMETHEUS")
 
-	logrus.WithFields(logrus.Fields{
-		"enabled":  enabled,
-		"port":     port,
-		"endpoint": endpoint,
-	}).Debug("Prometheus Config initialized")
+	logrus.WithFields(config.Fields(c)).Debug("Prometheus Config initialized")
 
-	return &Config{
-		Enabled:  enabled,
-		Port:     port,
-		Endpoint: endpoint,
-	}
+	return c
 }
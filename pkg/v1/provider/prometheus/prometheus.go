@@ -1,11 +1,9 @@
 package prometheus
 
 import (
-	"context"
-	"fmt"
-	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/httpserver"
 	"net/http"
-	"time"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
@@ -14,11 +12,9 @@ import (
 // Prometheus Provider.
 // Provides metrics to be used by a Prometheus collector.
 type Prometheus struct {
-	provider.AbstractRunProvider
+	httpserver.Server
 
 	Config *Config
-
-	srv *http.Server
 }
 
 // Creates a Prometheus Provider.
@@ -28,6 +24,11 @@ func New(config *Config) *Prometheus {
 	}
 }
 
+// Returns the invalid settings of the Prometheus configuration, if any (see provider.Configured).
+func (p *Prometheus) ConfigError() error {
+	return config.Err(p.Config)
+}
+
 // Creates an HTTP service on the configured port and endpoint, where metrics are published.
 func (p *Prometheus) Run() error {
 	if !p.Config.Enabled {
@@ -35,36 +36,26 @@ func (p *Prometheus) Run() error {
 		return nil
 	}
 
-	addr := fmt.Sprintf(":%d", p.Config.Port)
-
 	logEntry := logrus.WithFields(logrus.Fields{
-		"addr":     addr,
+		"port":     p.Config.Port,
 		"endpoint": p.Config.Endpoint,
 	})
 
 	mux := http.NewServeMux()
-	mux.Handle(p.Config.Endpoint, promhttp.Handler())
-	p.srv = &http.Server{Addr: addr, Handler: mux}
-	p.SetRunning(true)
-
-	logEntry.Info("Prometheus Provider launched")
-	if err := p.srv.ListenAndServe(); err != http.ErrServerClosed {
-		logEntry.WithError(err).Error("Prometheus Provider launch failed")
-		return err
-	}
+	p.Mount(mux)
+	return p.Serve("Prometheus Provider", p.Config.Port, p.Config.Server, mux, logEntry)
+}
 
+// Resets the HTTP service after it stopped, so the Stack can restart it (see provider.Restartable).
+func (p *Prometheus) Restart() error {
+	p.Reset()
 	return nil
 }
 
-func (p *Prometheus) Close() error {
-	if !p.Config.Enabled || p.srv == nil {
-		return nil
-	}
-
-	ctx, _ := context.WithTimeout(context.Background(), 1*time.Millisecond)
-	if err := p.srv.Shutdown(ctx); err != nil {
-		logrus.WithError(err).Error("Error while closing Prometheus server")
+// Registers the metrics handler on the mux, either its own or the one of the Admin Provider.
+func (p *Prometheus) Mount(mux *http.ServeMux) {
+	if !p.Config.Enabled {
+		return
 	}
-
-	return p.AbstractRunProvider.Close()
+	mux.Handle(p.Config.Endpoint, promhttp.Handler())
 }
@@ -1,6 +1,7 @@
 package prometheus
 
 import (
+	"context"
 	"fmt"
 	"github.azc.ext.hp.com/hp-business-platform/lib-core-go/pkg/v1/test"
 	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider"
@@ -10,6 +11,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/sirupsen/logrus"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"testing"
 	"time"
@@ -27,8 +29,8 @@ var _ = Describe("Prometheus provider", func() {
 		By("Creating and initializing the provider", func() {
 			p = New(&Config{
 				Enabled:  true,
-				Port:     defaultPort,
-				Endpoint: defaultEndpoint,
+				Port:     0,
+				Endpoint: "/metrics",
 			})
 			err := p.Init()
 			Expect(err).ToNot(HaveOccurred())
@@ -38,7 +40,9 @@ var _ = Describe("Prometheus provider", func() {
 				err := p.Run()
 				Expect(err).ToNot(HaveOccurred())
 			}()
-			err := provider.WaitForRunningProvider(p, 2*time.Second)
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			err := provider.WaitReady(ctx, p)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(p.IsRunning()).To(BeTrue())
 		})
@@ -47,7 +51,7 @@ var _ = Describe("Prometheus provider", func() {
 			testGauge.Add(201)
 		})
 		By("Testing a request", func() {
-			res, err := http.Get(fmt.Sprintf("http://localhost:%d%s", defaultPort, defaultEndpoint))
+			res, err := http.Get(fmt.Sprintf("http://localhost:%d%s", p.Addr().(*net.TCPAddr).Port, "/metrics"))
 			Expect(err).ToNot(HaveOccurred())
 			Expect(res).ToNot(BeNil())
 			Expect(res.StatusCode).To(Equal(200))
@@ -1,5 +1,10 @@
 package provider
 
+import (
+	"context"
+	"sync"
+)
+
 // Provider.
 // Enables an application to add a piece of functionality very quickly.
 // This normally results a connection to an external service being setup.
@@ -19,6 +24,98 @@ type RunProvider interface {
 	IsRunning() bool // Returns true only after the Provider has fully started up (making it usable by other functions).
 }
 
+// DependentProvider.
+// A DependentProvider declares the Providers it relies on.
+// The Stack uses these to initialize and launch dependencies first and close them last.
+type DependentProvider interface {
+	Provider
+
+	Dependencies() []Provider // Returns the Providers that need to be initialized (and running, if runnable) before this Provider. Nil entries are ignored.
+}
+
+// Registry.
+// Allows looking up Providers by type or name. Implemented by the Stack.
+type Registry interface {
+	Lookup(target interface{}) bool  // Sets target (a pointer to a Provider type or interface) to the first matching Provider. Returns false if none was found, leaving target untouched.
+	LookupName(name string) Provider // Returns the Provider with the given name (see Name()), or nil if none was found.
+}
+
+// Resolver.
+// A Resolver looks up its dependencies in the Registry, instead of (or next to) receiving them in its constructor.
+// The Stack resolves Providers before determining the order in which they are initialized, so resolved dependencies should be returned by Dependencies().
+type Resolver interface {
+	Resolve(registry Registry) error // Looks up dependencies that weren't set yet. Should return an error if a required dependency can't be found.
+}
+
+// ContextInitializer.
+// A Provider implementing this interface is initialized by the Stack using InitContext() instead of Init().
+type ContextInitializer interface {
+	InitContext(ctx context.Context) error // Initializes the Provider, giving up once the context is done.
+}
+
+// ContextCloser.
+// A Provider implementing this interface is closed by the Stack using CloseContext() instead of Close().
+// This allows a Provider to gracefully shut down (e.g. drain in-flight requests) within the deadline of the context.
+type ContextCloser interface {
+	CloseContext(ctx context.Context) error // Closes the Provider, forcefully stopping anything still running once the context is done.
+}
+
+// ContextProvider.
+// A Provider whose whole lifecycle is context-aware.
+type ContextProvider interface {
+	Provider
+	ContextInitializer
+	ContextCloser
+}
+
+// Phases of the graceful shutdown sequence of the Stack, which happen before any Provider is closed.
+type ShutdownPhase int
+
+const (
+	ShutdownPhaseDrain ShutdownPhase = iota // Stop attracting new traffic (e.g. fail readiness probes and GRPC health checks). Followed by the drain delay, so load balancers can deregister the application.
+	ShutdownPhaseStop                       // The drain delay has passed and the Providers are about to be closed.
+)
+
+// ShutdownListener.
+// A Provider implementing this interface is notified by the Stack as it goes through the graceful shutdown sequence.
+type ShutdownListener interface {
+	OnShutdown(phase ShutdownPhase) // Called once for every phase. Should return quickly, in-flight requests are still being served.
+}
+
+// Reconfigurable.
+// A Provider implementing this interface applies configuration changes while it runs, without a restart.
+// The Stack calls Reconfigure() on all initialized Providers whenever the configuration file changes (see config.Watch()).
+type Reconfigurable interface {
+	// Reloads the settings of the Provider (see config.Reload()) and applies the ones that can change live.
+	// Returns an error if the new settings are invalid, in which case the running configuration is kept.
+	Reconfigure() error
+}
+
+// Configured.
+// A Provider implementing this interface reports the invalid settings of its configuration (see config.Err()).
+// The Stack checks the configuration of all Providers before initializing any of them, so an invalid configuration fails at startup with all of its problems at once.
+type Configured interface {
+	ConfigError() error // Returns a *config.Error listing the invalid settings, or nil if the configuration is valid.
+}
+
+// Restartable.
+// A RunProvider implementing this interface can be restarted by the Stack once its Run() method returned (see stack.Supervise()).
+// RunProviders that can't run more than once (e.g. because services are registered on them once) shouldn't implement it.
+type Restartable interface {
+	RunProvider
+
+	Restart() error // Resets the RunProvider after its Run() method returned, so it can run again. Called by the Stack before every restart.
+}
+
+// Readiness.
+// A RunProvider implementing this interface signals readiness and failure through channels, so others don't need to poll IsRunning().
+// Implemented by the AbstractRunProvider.
+type Readiness interface {
+	Ready() <-chan struct{} // Closed once the RunProvider is running.
+	Done() <-chan struct{}  // Closed once the RunProvider stopped running or failed.
+	Err() error             // Returns the reason the RunProvider failed, once Done() is closed. Nil if it stopped normally.
+}
+
 // Abstract Provider.
 type AbstractProvider struct {
 	Provider
@@ -36,10 +133,15 @@ func (p *AbstractProvider) Close() error {
 
 // Abstract RunProvider.
 // Does not extend the Run() method, since Providers that don't actually run shouldn't be a RunProvider.
+// Its running state is safe to use from multiple goroutines.
 type AbstractRunProvider struct {
 	RunProvider
 
+	mu      sync.Mutex
 	running bool
+	ready   chan struct{}
+	done    chan struct{}
+	err     error
 }
 
 // Override if the RunProvider needs to be initialized.
@@ -55,10 +157,98 @@ func (p *AbstractRunProvider) Close() error {
 
 // Returns true after the RunProvider has started.
 func (p *AbstractRunProvider) IsRunning() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
 	return p.running
 }
 
 // Used by extending providers to update their running status. Should be called with true once the Run() method has almost finished (just before the blocking part).
+// Setting it to true closes the Ready() channel, setting it to false afterwards closes the Done() channel.
 func (p *AbstractRunProvider) SetRunning(running bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.init()
+	if running == p.running {
+		return
+	}
 	p.running = running
+	if running {
+		if isClosed(p.done) {
+			// The RunProvider is running again (e.g. restarted by the Stack), so it gets a fresh lifecycle.
+			p.ready, p.done, p.err = make(chan struct{}), make(chan struct{}), nil
+		}
+		close(p.ready)
+	} else if !isClosed(p.done) {
+		close(p.done)
+	}
+}
+
+// Used by extending providers (or the Stack) to signal that the RunProvider failed, closing the Done() channel.
+func (p *AbstractRunProvider) SetFailed(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.init()
+	p.running = false
+	if !isClosed(p.done) {
+		p.err = err
+		close(p.done)
+	}
+}
+
+// Used by extending providers that can be restarted (see Restartable), giving the RunProvider a fresh lifecycle once it stopped running or failed.
+func (p *AbstractRunProvider) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.init()
+	if !isClosed(p.done) {
+		close(p.done)
+	}
+	p.running = false
+	p.ready, p.done, p.err = make(chan struct{}), make(chan struct{}), nil
+}
+
+// Returns a channel that is closed once the RunProvider is running.
+func (p *AbstractRunProvider) Ready() <-chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.init()
+	return p.ready
+}
+
+// Returns a channel that is closed once the RunProvider stopped running or failed.
+func (p *AbstractRunProvider) Done() <-chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.init()
+	return p.done
+}
+
+// Returns the error passed to SetFailed(), if any.
+func (p *AbstractRunProvider) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.err
+}
+
+// Lazily creates the channels, so the zero value is usable. Should only be called while holding the lock.
+func (p *AbstractRunProvider) init() {
+	if p.ready == nil {
+		p.ready, p.done = make(chan struct{}), make(chan struct{})
+	}
+}
+
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
 }
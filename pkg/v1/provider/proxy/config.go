@@ -1,61 +1,45 @@
 package proxy
 
 import (
+	"fmt"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/httpserver"
 	"github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
-)
-
-const (
-	defaultPort      = 4040
-	defaultEndpoint  = "/"
-	defaultTargetURL = "http://localhost:8080"
+	"net/url"
 )
 
 // Configuration for the Proxy Provider.
 type Config struct {
-	Enabled   bool   // Whether or not the the HTTP service should be running.
-	Debug     bool   // Whether or not to log the request and response bodies (URL and status will always be logged).
-	Port      int    // Port on which to start the HTTP service.
-	Prefix    string // Prefix to use in logs and to get the correct service
-	Endpoint  string // Endpoint on which to expose the proxy.
-	TargetURL string // URL to where the proxy requests should go.
+	config.Result
+
+	Enabled   bool               `env:"ENABLED" default:"true"`                  // Whether or not the the HTTP service should be running.
+	Debug     bool               `env:"DEBUG" default:"false"`                   // Whether or not to log the request and response bodies (URL and status will always be logged).
+	Port      int                `env:"PORT" default:"4040" min:"0" max:"65535"` // Port on which to start the HTTP service.
+	Server    *httpserver.Config // Settings of the HTTP server (timeouts, TLS...). Defaults are used if nil.
+	Prefix    string             // Prefix to use in logs and to get the correct service
+	Endpoint  string             `env:"ENDPOINT" default:"/"`                                       // Endpoint on which to expose the proxy.
+	TargetURL string             `env:"TARGET_URL" default:"http://localhost:8080" required:"true"` // URL to where the proxy requests should go.
 }
 
 // Initializes the configuration from environment variables.
 func NewConfigFromEnv(prefix string) *Config {
-	v := viper.New()
-	v.SetEnvPrefix(prefix)
-	v.AutomaticEnv()
-
-	v.SetDefault("ENABLED", true)
-	enabled := v.GetBool("ENABLED")
-
-	v.SetDefault("DEBUG", false)
-	debug := v.GetBool("DEBUG")
+	c := &Config{Prefix: prefix}
+	_ = config.Load(prefix, c)
+	c.Server = httpserver.NewConfigFromEnv(prefix)
 
-	v.SetDefault("PORT", defaultPort)
-	port := v.GetInt("PORT")
+	logrus.WithFields(config.Fields(c)).Debugf("%s Proxy Config initialized", prefix)
 
-	v.SetDefault("ENDPOINT", defaultEndpoint)
-	endpoint := v.GetString("ENDPOINT")
-
-	v.SetDefault("TARGET_URL", defaultTargetURL)
-	targetURL := v.GetString("TARGET_URL")
-
-	logrus.WithFields(logrus.Fields{
-		"enabled":    enabled,
-		"debug":      debug,
-		"port":       port,
-		"endpoint":   endpoint,
-		"target_url": targetURL,
-	}).Debugf("%s Proxy Config initialized", prefix)
+	return c
+}
 
-	return &Config{
-		Enabled:   enabled,
-		Debug:     debug,
-		Port:      port,
-		Prefix:    prefix,
-		Endpoint:  endpoint,
-		TargetURL: targetURL,
+// Checks the target URL can be proxied to.
+func (c *Config) Validate() error {
+	target, err := url.Parse(c.TargetURL)
+	if err != nil {
+		return fmt.Errorf("invalid target URL: %w", err)
+	}
+	if target.Scheme == "" || target.Host == "" {
+		return fmt.Errorf("target URL %s should be absolute", config.Redact(c.TargetURL))
 	}
+	return nil
 }
@@ -1,26 +1,26 @@
 package proxy
 
 import (
-	"context"
-	"fmt"
-	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/httpserver"
 	"github.com/sirupsen/logrus"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
 	"strings"
-	"time"
+	"sync"
 )
 
 // Proxy Provider.
 // Creates a reverse proxy for communicating with an internal service.
 type Proxy struct {
-	provider.AbstractRunProvider
+	httpserver.Server
 
-	Config       *Config
-	ReverseProxy *httputil.ReverseProxy
+	Config *Config
 
-	srv *http.Server
+	mu     sync.RWMutex
+	proxy  *httputil.ReverseProxy // Replaced when the target URL changes (see Reconfigure()).
+	loaded string                 // Target URL of the environment variables and configuration file when last loaded, so only changes to it are applied.
 }
 
 // Creates a Proxy Provider.
@@ -30,15 +30,24 @@ func New(config *Config) *Proxy {
 	}
 }
 
+// Returns the invalid settings of the Proxy configuration, if any (see provider.Configured).
+func (p *Proxy) ConfigError() error {
+	return config.Err(p.Config)
+}
+
 func (p *Proxy) Init() error {
-	targetURL, err := url.Parse(p.Config.TargetURL)
+	reverseProxy, err := p.newReverseProxy(p.Config.TargetURL)
 	if err != nil {
-		logrus.WithField("target_url", p.Config.TargetURL).WithError(err).Errorf("%s Proxy Provider initialization failed", p.Config.Prefix)
+		logrus.WithField("target_url", config.Redact(p.Config.TargetURL)).WithError(err).Errorf("%s Proxy Provider initialization failed", p.Config.Prefix)
 		return err
 	}
-	p.ReverseProxy = httputil.NewSingleHostReverseProxy(targetURL)
-	p.ReverseProxy.Transport = &loggingTransport{Config: p.Config}
-	logrus.WithField("target_url", targetURL).Infof("%s Proxy initialized", strings.Title(p.Config.Prefix))
+	p.proxy = reverseProxy
+
+	c := &Config{}
+	_ = config.Reload(p.Config.Prefix, c)
+	p.loaded = c.TargetURL
+
+	logrus.WithField("target_url", config.Redact(p.Config.TargetURL)).Infof("%s Proxy initialized", strings.Title(p.Config.Prefix))
 	return nil
 }
 
@@ -48,40 +57,63 @@ func (p *Proxy) Run() error {
 		return nil
 	}
 
-	addr := fmt.Sprintf(":%d", p.Config.Port)
-
 	logEntry := logrus.WithFields(logrus.Fields{
-		"addr":     addr,
+		"port":     p.Config.Port,
 		"endpoint": p.Config.Endpoint,
 	})
 
 	mux := http.NewServeMux()
 	mux.HandleFunc(p.Config.Endpoint, func(res http.ResponseWriter, req *http.Request) {
 		req.Host = req.URL.Host
-		p.ReverseProxy.ServeHTTP(res, req)
+		p.ReverseProxy().ServeHTTP(res, req)
 	})
 
-	p.srv = &http.Server{Addr: addr, Handler: mux}
-	p.SetRunning(true)
+	return p.Serve(strings.Title(p.Config.Prefix)+" Proxy", p.Config.Port, p.Config.Server, mux, logEntry)
+}
 
-	logEntry.Infof("%s Proxy launched", strings.Title(p.Config.Prefix))
-	if err := p.srv.ListenAndServe(); err != http.ErrServerClosed {
-		logEntry.WithError(err).Errorf("%s Proxy launch failed", strings.Title(p.Config.Prefix))
+// Applies a changed target URL, so requests are proxied to the new target without a restart.
+// The target URL is only replaced when the environment variables or the configuration file changed it, so a target URL set in code is kept otherwise.
+// Requests that are in flight complete against the previous target. Other settings only apply after a restart.
+func (p *Proxy) Reconfigure() error {
+	c := &Config{}
+	if err := config.Reload(p.Config.Prefix, c); err != nil {
 		return err
 	}
 
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if c.TargetURL == p.loaded {
+		return nil
+	}
+	reverseProxy, err := p.newReverseProxy(c.TargetURL)
+	if err != nil {
+		return err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"from": config.Redact(p.Config.TargetURL),
+		"to":   config.Redact(c.TargetURL),
+	}).Infof("%s Proxy target changed", strings.Title(p.Config.Prefix))
+	p.Config.TargetURL, p.proxy, p.loaded = c.TargetURL, reverseProxy, c.TargetURL
 	return nil
 }
 
-func (p *Proxy) Close() error {
-	if !p.Config.Enabled || p.srv == nil {
-		return p.AbstractRunProvider.Close()
-	}
+// Returns the reverse proxy to the current target, which is replaced when the target URL changes (see Reconfigure()).
+func (p *Proxy) ReverseProxy() *httputil.ReverseProxy {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
 
-	ctx, _ := context.WithTimeout(context.Background(), 1*time.Millisecond)
-	if err := p.srv.Shutdown(ctx); err != nil {
-		logrus.WithError(err).Errorf("Error while closing %s Proxy server", strings.Title(p.Config.Prefix))
-	}
+	return p.proxy
+}
 
-	return p.AbstractRunProvider.Close()
+func (p *Proxy) newReverseProxy(target string) (*httputil.ReverseProxy, error) {
+	targetURL, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+	reverseProxy := httputil.NewSingleHostReverseProxy(targetURL)
+	// The transport gets its own copy, as the configuration changes while requests are in flight.
+	transportConfig := *p.Config
+	reverseProxy.Transport = &loggingTransport{Config: &transportConfig}
+	return reverseProxy, nil
 }
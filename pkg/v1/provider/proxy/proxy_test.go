@@ -1,6 +1,7 @@
 package proxy
 
 import (
+	"context"
 	"fmt"
 	"github.azc.ext.hp.com/hp-business-platform/lib-core-go/pkg/v1/test"
 	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider"
@@ -8,13 +9,21 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/sirupsen/logrus"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"net/http/httputil"
+	"os"
 	"strings"
 	"testing"
 	"time"
 )
 
+const (
+	endpoint  = "/"
+	targetURL = "http://localhost:8080"
+)
+
 func TestProxy(t *testing.T) {
 	RegisterFailHandlerWithT(t, Fail)
 	RunSpecsWithDefaultAndCustomReporters(t, "Proxy provider test", test.LoadCustomReporters("../../test_provider_proxy.xml"))
@@ -29,9 +38,9 @@ var _ = Describe("Proxy provider", func() {
 			p = New(&Config{
 				Enabled:   true,
 				Debug:     true,
-				Port:      defaultPort,
-				Endpoint:  defaultEndpoint,
-				TargetURL: defaultTargetURL + "/testing",
+				Port:      0,
+				Endpoint:  endpoint,
+				TargetURL: targetURL + "/testing",
 				Prefix:    "TEST_SERVICE",
 			})
 			err := p.Init()
@@ -43,12 +52,14 @@ var _ = Describe("Proxy provider", func() {
 				err := p.Run()
 				Expect(err).ToNot(HaveOccurred())
 			}()
-			err := provider.WaitForRunningProvider(p, 2*time.Second)
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			err := provider.WaitReady(ctx, p)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(p.IsRunning()).To(BeTrue())
 		})
 		By("Testing a GET request to default path", func() {
-			res, err := http.Get(fmt.Sprintf("http://localhost:%d%s", defaultPort, defaultEndpoint))
+			res, err := http.Get(fmt.Sprintf("http://localhost:%d%s", p.Addr().(*net.TCPAddr).Port, endpoint))
 			Expect(err).ToNot(HaveOccurred())
 			Expect(res).ToNot(BeNil())
 			Expect(res.StatusCode).To(Equal(200))
@@ -59,7 +70,7 @@ var _ = Describe("Proxy provider", func() {
 			Expect(body).To(Equal("GET:"))
 		})
 		By("Testing a GET request to sub path", func() {
-			res, err := http.Get(fmt.Sprintf("http://localhost:%d%s", defaultPort, defaultEndpoint+"sub/somewhere"))
+			res, err := http.Get(fmt.Sprintf("http://localhost:%d%s", p.Addr().(*net.TCPAddr).Port, endpoint+"sub/somewhere"))
 			Expect(err).ToNot(HaveOccurred())
 			Expect(res).ToNot(BeNil())
 			Expect(res.StatusCode).To(Equal(200))
@@ -71,7 +82,7 @@ var _ = Describe("Proxy provider", func() {
 		})
 		By("Testing a POST request", func() {
 			reqBody := "bla"
-			res, err := http.Post(fmt.Sprintf("http://localhost:%d%s", defaultPort, defaultEndpoint), "text/plain", strings.NewReader(reqBody))
+			res, err := http.Post(fmt.Sprintf("http://localhost:%d%s", p.Addr().(*net.TCPAddr).Port, endpoint), "text/plain", strings.NewReader(reqBody))
 			Expect(err).ToNot(HaveOccurred())
 			Expect(res).ToNot(BeNil())
 			Expect(res.StatusCode).To(Equal(200))
@@ -81,6 +92,36 @@ var _ = Describe("Proxy provider", func() {
 			body := string(bytes)
 			Expect(body).To(Equal("POST:" + reqBody))
 		})
+		By("Keeping the target set in code until the environment changes it", func() {
+			Expect(p.Reconfigure()).To(Succeed())
+			Expect(p.Config.TargetURL).To(Equal(targetURL + "/testing"))
+		})
+		By("Switching to another target while running", func() {
+			other := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+				_, _ = res.Write([]byte("other:" + req.URL.Path))
+			}))
+			defer other.Close()
+			_ = os.Setenv("TEST_SERVICE_TARGET_URL", other.URL)
+			defer os.Unsetenv("TEST_SERVICE_TARGET_URL")
+			Expect(p.Reconfigure()).To(Succeed())
+			Expect(p.Config.TargetURL).To(Equal(other.URL))
+
+			get := func() string {
+				res, err := http.Get(fmt.Sprintf("http://localhost:%d%s", p.Addr().(*net.TCPAddr).Port, endpoint+"path"))
+				Expect(err).ToNot(HaveOccurred())
+				bytes, err := ioutil.ReadAll(res.Body)
+				Expect(err).ToNot(HaveOccurred())
+				return string(bytes)
+			}
+			Expect(get()).To(Equal("other:/path"))
+
+			By("Rejecting an invalid target", func() {
+				_ = os.Setenv("TEST_SERVICE_TARGET_URL", "not a url")
+				Expect(p.Reconfigure()).ToNot(Succeed())
+				Expect(p.Config.TargetURL).To(Equal(other.URL))
+				Expect(get()).To(Equal("other:/path"))
+			})
+		})
 	})
 })
 
@@ -0,0 +1,29 @@
+package status
+
+import (
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/httpserver"
+	"github.com/sirupsen/logrus"
+)
+
+// Configuration for the Status Provider.
+type Config struct {
+	config.Result
+
+	Enabled         bool               `env:"ENABLED" default:"true"`                  // Whether or not the the HTTP service should be running.
+	Port            int                `env:"PORT" default:"8001" min:"0" max:"65535"` // Port on which to start the HTTP service.
+	Server          *httpserver.Config // Settings of the HTTP server (timeouts, TLS...). Defaults are used if nil.
+	Endpoint        string             `env:"ENDPOINT" default:"/status"`          // Endpoint on which to expose the status of the Stack.
+	VersionEndpoint string             `env:"VERSION_ENDPOINT" default:"/version"` // Endpoint on which to expose the build metadata of the application, if an App Provider is in the Stack. Empty disables it.
+}
+
+// Initializes the configuration from environment variables.
+func NewConfigFromEnv() *Config {
+	c := &Config{}
+	_ = config.Load("STATUS", c)
+	c.Server = httpserver.NewConfigFromEnv("STATUS")
+
+	logrus.WithFields(config.Fields(c)).Debug("Status Config initialized")
+
+	return c
+}
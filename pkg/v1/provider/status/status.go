@@ -0,0 +1,84 @@
+package status
+
+import (
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/app"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/httpserver"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/stack"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Status Provider.
+// Provides an admin endpoint publishing the lifecycle state of every Provider in the Stack,
+// and one publishing the build metadata of the application (see app.BuildInfo).
+type Status struct {
+	httpserver.Server
+
+	Config      *Config
+	stack       *stack.Stack
+	appProvider *app.App
+}
+
+// Creates a Status Provider.
+func New(config *Config, st *stack.Stack) *Status {
+	return &Status{
+		Config: config,
+		stack:  st,
+	}
+}
+
+// Returns the invalid settings of the Status configuration, if any (see provider.Configured).
+func (p *Status) ConfigError() error {
+	return config.Err(p.Config)
+}
+
+// Status depends on the (optional) App Provider.
+func (p *Status) Dependencies() []provider.Provider {
+	return []provider.Provider{p.appProvider}
+}
+
+// Looks up the optional App Provider in the Stack, used to publish the build metadata.
+func (p *Status) Resolve(registry provider.Registry) error {
+	if p.appProvider == nil {
+		registry.Lookup(&p.appProvider)
+	}
+	return nil
+}
+
+// Creates an HTTP service on the configured port and endpoint, where the status of the Stack is published.
+func (p *Status) Run() error {
+	if !p.Config.Enabled {
+		logrus.Info("Status Provider not enabled")
+		return nil
+	}
+
+	logEntry := logrus.WithFields(logrus.Fields{
+		"port":             p.Config.Port,
+		"endpoint":         p.Config.Endpoint,
+		"version_endpoint": p.Config.VersionEndpoint,
+	})
+
+	mux := http.NewServeMux()
+	p.Mount(mux)
+	return p.Serve("Status Provider", p.Config.Port, p.Config.Server, mux, logEntry)
+}
+
+// Resets the HTTP service after it stopped, so the Stack can restart it (see provider.Restartable).
+func (p *Status) Restart() error {
+	p.Reset()
+	return nil
+}
+
+// Registers the status handler on the mux, either its own or the one of the Admin Provider.
+func (p *Status) Mount(mux *http.ServeMux) {
+	if !p.Config.Enabled {
+		return
+	}
+	mux.Handle(p.Config.Endpoint, p.stack.StatusHandler())
+	if p.appProvider != nil && p.Config.VersionEndpoint != "" {
+		mux.Handle(p.Config.VersionEndpoint, p.appProvider.VersionHandler())
+	}
+}
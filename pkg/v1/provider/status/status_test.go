@@ -0,0 +1,95 @@
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.azc.ext.hp.com/hp-business-platform/lib-core-go/pkg/v1/test"
+	"github.azc.ext.hp.com/hp-business-platform/lib-core-go/pkg/v1/version"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/app"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/stack"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	"net"
+	"net/http"
+	"runtime"
+	"testing"
+)
+
+func TestStatus(t *testing.T) {
+	RegisterFailHandlerWithT(t, Fail)
+	RunSpecsWithDefaultAndCustomReporters(t, "Status provider test", test.LoadCustomReporters("../../test_provider_status.xml"))
+}
+
+var _ = Describe("Status provider", func() {
+	It("Publishes the status of the stack", func() {
+		logrus.SetLevel(logrus.DebugLevel)
+		st := stack.New()
+		var p *Status
+		By("Creating and initializing the provider", func() {
+			p = New(&Config{
+				Enabled:  true,
+				Port:     0,
+				Endpoint: "/status",
+			}, st)
+			st.Add(p)
+		})
+		By("Running the stack", func() {
+			go func() {
+				err := st.Run(context.Background())
+				Expect(err).ToNot(HaveOccurred())
+			}()
+			Eventually(p.IsRunning).Should(BeTrue())
+		})
+		By("Testing a request", func() {
+			res, err := http.Get(fmt.Sprintf("http://localhost:%d%s", p.Addr().(*net.TCPAddr).Port, "/status"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.StatusCode).To(Equal(200))
+			Expect(res.Header.Get("Content-Type")).To(Equal("application/json"))
+
+			var body struct {
+				Providers []struct {
+					Name  string `json:"name"`
+					State string `json:"state"`
+				} `json:"providers"`
+			}
+			Expect(json.NewDecoder(res.Body).Decode(&body)).To(Succeed())
+			Expect(body.Providers).To(HaveLen(1))
+			Expect(body.Providers[0].Name).To(Equal(provider.Name(p)))
+			Expect(body.Providers[0].State).To(Equal(string(stack.StateRunning)))
+		})
+		By("Closing the stack", func() {
+			Expect(st.Close(context.Background())).To(Succeed())
+			Expect(p.IsRunning()).To(BeFalse())
+		})
+	})
+	It("Publishes the build metadata of the application", func() {
+		version.BuildString = "1.2.3 a722bdb 2018-01-09T22:32:37+01:00 go version go1.14 linux/amd64"
+		st := stack.New()
+		defer func() {
+			Expect(st.Close(context.Background())).To(Succeed())
+		}()
+		appProvider := app.New(&app.Config{Name: "status-test", BasePath: "/"})
+		p := New(&Config{Enabled: true, Port: 0, Endpoint: "/status", VersionEndpoint: "/version"}, st)
+		st.Add(appProvider, p)
+		go func() {
+			_ = st.Run(context.Background())
+		}()
+		Eventually(p.IsRunning).Should(BeTrue())
+
+		res, err := http.Get(fmt.Sprintf("http://localhost:%d%s", p.Addr().(*net.TCPAddr).Port, "/version"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.StatusCode).To(Equal(200))
+		Expect(res.Header.Get("Content-Type")).To(Equal("application/json"))
+
+		var info app.BuildInfo
+		Expect(json.NewDecoder(res.Body).Decode(&info)).To(Succeed())
+		Expect(info.Name).To(Equal("status-test"))
+		Expect(info.Version).To(Equal("1.2.3"))
+		Expect(info.Commit).To(Equal("a722bdb"))
+		Expect(info.BuildTime).To(Equal("2018-01-09T22:32:37+01:00"))
+		Expect(info.GoVersion).To(Equal(runtime.Version()))
+	})
+})
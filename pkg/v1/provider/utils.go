@@ -8,28 +8,64 @@ import (
 	"time"
 )
 
-// Utility function that allows waiting for a provider to run.
-// Mostly usable from other providers that have a dependency.
+// Utility function that allows waiting for a provider to run, giving up after the given number of seconds.
+// The timeout is a plain count of seconds despite its time.Duration type: pass 5, not 5*time.Second, which would
+// wait for about 158 years.
+// Deprecated: use WaitReady(ctx, p) with a context carrying the timeout, which also returns as soon as the provider failed.
 func WaitForRunningProvider(p RunProvider, timeoutSeconds time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeoutSeconds*time.Second)
+	defer cancel()
+
+	return WaitReady(ctx, p)
+}
+
+// Utility function that waits for a provider to run, until the context is done.
+// Returns an error if the provider failed or stopped before it was running.
+// Mostly usable from other providers that have a dependency.
+func WaitReady(ctx context.Context, p RunProvider) error {
 	if p.IsRunning() {
 		// No need to wait if provider is already running.
 		return nil
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeoutSeconds*time.Second)
-	defer cancel()
+	name := Name(p)
+	readiness, ok := p.(Readiness)
+	if !ok {
+		return pollRunning(ctx, p)
+	}
+
+	logrus.Debugf("Waiting for %s to run...", name)
+	select {
+	case <-readiness.Ready():
+		logrus.Debugf("%s is running", name)
+		return nil
+	case <-readiness.Done():
+		if err := readiness.Err(); err != nil {
+			return fmt.Errorf("%s failed: %w", name, err)
+		}
+		return fmt.Errorf("%s stopped before it was running", name)
+	case <-ctx.Done():
+		return fmt.Errorf("time exceeded for %s to run", name)
+	}
+}
 
+// Polls IsRunning() of providers that don't signal their readiness.
+func pollRunning(ctx context.Context, p RunProvider) error {
 	name := Name(p)
-	logrus.WithField("timeout", timeoutSeconds).Debugf("Waiting for %s to run...", name)
+	logrus.Debugf("Waiting for %s to run...", name)
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
 	for {
 		if p.IsRunning() {
 			logrus.Debugf("%s is running", name)
 			return nil
 		}
-		if ctx.Err() != nil {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
 			return fmt.Errorf("time exceeded for %s to run", name)
 		}
-		time.Sleep(10 * time.Millisecond)
 	}
 }
 
@@ -1,6 +1,8 @@
 package provider
 
 import (
+	"context"
+	"errors"
 	"github.azc.ext.hp.com/hp-business-platform/lib-core-go/pkg/v1/test"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -36,9 +38,9 @@ var _ = Describe("Utils", func() {
 			p1 := &TestProvider1{}
 			It("Waits for the provider to start", func() {
 				p2 := &TestProvider2{other: p1}
-				var p2Err error
+				p2Err := make(chan error, 1)
 				go func() {
-					p2Err = p2.Run()
+					p2Err <- p2.Run()
 				}()
 				Expect(p1.IsRunning()).To(BeFalse())
 				Expect(p2.IsRunning()).To(BeFalse())
@@ -47,9 +49,7 @@ var _ = Describe("Utils", func() {
 				p1Err := p1.Run()
 				Expect(p1Err).ToNot(HaveOccurred())
 				Expect(p1.IsRunning()).To(BeTrue())
-				// Give p2 time to detect that p1 is running now.
-				time.Sleep(11 * time.Millisecond)
-				Expect(p2Err).ToNot(HaveOccurred())
+				Eventually(p2Err).Should(Receive(BeNil()))
 				Expect(p2.IsRunning()).To(BeTrue())
 			})
 		})
@@ -63,6 +63,60 @@ var _ = Describe("Utils", func() {
 			})
 		})
 	})
+	Context("Signalling readiness", func() {
+		It("Closes the ready channel once running and the done channel once stopped", func() {
+			p := &TestProvider1{}
+			Expect(p.Ready()).ToNot(BeClosed())
+			Expect(p.Done()).ToNot(BeClosed())
+			_ = p.Run()
+			Expect(p.Ready()).To(BeClosed())
+			Expect(p.Done()).ToNot(BeClosed())
+			_ = p.Close()
+			Expect(p.Done()).To(BeClosed())
+			Expect(p.Err()).ToNot(HaveOccurred())
+		})
+		It("Starts a fresh lifecycle when running again", func() {
+			p := &TestProvider1{}
+			_ = p.Run()
+			p.SetFailed(errors.New("failed"))
+			Expect(p.IsRunning()).To(BeFalse())
+			Expect(p.Err()).To(HaveOccurred())
+			_ = p.Run()
+			Expect(p.Ready()).To(BeClosed())
+			Expect(p.Done()).ToNot(BeClosed())
+			Expect(p.Err()).ToNot(HaveOccurred())
+		})
+	})
+	Context("Waiting until a run provider is ready", func() {
+		It("Returns once the provider is running", func() {
+			p := &TestProvider1{}
+			go func() {
+				time.Sleep(10 * time.Millisecond)
+				_ = p.Run()
+			}()
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			Expect(WaitReady(ctx, p)).To(Succeed())
+		})
+		It("Returns the error of a provider that failed", func() {
+			p := &TestProvider1{}
+			errFailed := errors.New("failed")
+			go func() {
+				time.Sleep(10 * time.Millisecond)
+				p.SetFailed(errFailed)
+			}()
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			err := WaitReady(ctx, p)
+			Expect(errors.Is(err, errFailed)).To(BeTrue())
+		})
+		It("Returns an error once the context is done", func() {
+			p := &TestProvider1{}
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			Expect(WaitReady(ctx, p)).ToNot(Succeed())
+		})
+	})
 })
 
 type TestProvider1 struct {
@@ -0,0 +1,110 @@
+package stack
+
+import (
+	"context"
+	p "github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider"
+	"sync"
+)
+
+// Wraps a Stack, so it can be managed by a parent Stack as a single RunProvider.
+// The parent initializes the child Stack (and all of its Providers) as it initializes the child Provider,
+// runs it as long as the parent is running, forwards the shutdown sequence to it and closes it (within its share of the shutdown budget).
+// A child Stack doesn't listen to shutdown signals itself.
+type childProvider struct {
+	p.AbstractRunProvider
+
+	stack *Stack
+
+	mu       sync.Mutex
+	cancel   context.CancelFunc
+	stopped  chan struct{}
+	closeErr error
+}
+
+// Returns a RunProvider managing the Stack, allowing it to be added to a parent Stack as a child.
+// This allows composing a service out of several Stacks, or starting several fully wired services next to each other.
+// Always returns the same RunProvider, so other Providers can declare it as a dependency.
+func (s *Stack) AsProvider() p.RunProvider {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.child == nil {
+		s.child = &childProvider{stack: s}
+	}
+	return s.child
+}
+
+// Initializes all Providers of the child Stack.
+func (c *childProvider) Init() error {
+	return c.InitContext(context.Background())
+}
+
+// Initializes all Providers of the child Stack, passing the context to those implementing provider.ContextInitializer.
+func (c *childProvider) InitContext(ctx context.Context) error {
+	return c.stack.initAll(ctx)
+}
+
+// Launches the child Stack and blocks until it is closed by the parent Stack, or any of its RunProviders fails.
+// In the latter case, the child Stack is closed and the errors are returned.
+func (c *childProvider) Run() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	defer close(stopped)
+
+	c.mu.Lock()
+	c.cancel, c.stopped = cancel, stopped
+	c.mu.Unlock()
+
+	var err error
+	c.stack.runOnce.Do(func() {
+		if err = c.stack.start(ctx); err != nil {
+			return
+		}
+		c.SetRunning(true)
+
+		if failed := c.stack.wait(ctx, nil); failed {
+			err = c.stack.stop()
+			return
+		}
+		// Closed by the parent Stack: the errors are returned by CloseContext().
+		c.closeErr = c.stack.stop()
+	})
+	return err
+}
+
+// Forwards the shutdown sequence of the parent Stack to the Providers of the child Stack.
+func (c *childProvider) OnShutdown(phase p.ShutdownPhase) {
+	c.stack.notifyShutdown(phase)
+}
+
+// Closes the child Stack.
+func (c *childProvider) Close() error {
+	return c.CloseContext(context.Background())
+}
+
+// Closes the child Stack, waiting until it is closed or the context is done.
+// The child Stack uses its own configuration for the shutdown budget of its Providers.
+func (c *childProvider) CloseContext(ctx context.Context) error {
+	c.mu.Lock()
+	cancel, stopped := c.cancel, c.stopped
+	c.mu.Unlock()
+
+	if cancel == nil {
+		// Never launched, so only needs to be closed.
+		if err := c.stack.Close(ctx); err != nil {
+			return err
+		}
+		return c.AbstractRunProvider.Close()
+	}
+
+	cancel()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if c.closeErr != nil {
+		return c.closeErr
+	}
+	return c.AbstractRunProvider.Close()
+}
@@ -0,0 +1,72 @@
+package stack
+
+import (
+	"fmt"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
+	"github.com/sirupsen/logrus"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Signals that can be configured to trigger the shutdown sequence.
+var signals = map[string]os.Signal{
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGINT":  syscall.SIGINT,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGTERM": syscall.SIGTERM,
+}
+
+// Configuration for the Stack.
+type Config struct {
+	config.Result
+
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" default:"25" min:"0"`     // Maximum duration for closing all Providers. Split across the Providers as they are closed. Zero means no limit. The default stays below the default Kubernetes termination grace period of 30 seconds.
+	ShutdownSignals Signals       `env:"SHUTDOWN_SIGNALS" default:"SIGINT,SIGTERM"` // Signals that trigger the shutdown sequence. A second signal forces the application to exit immediately.
+	DrainDelay      time.Duration `env:"DRAIN_DELAY" default:"0" min:"0"`           // Duration to wait after readiness starts failing and before closing Providers, so load balancers can deregister the application.
+}
+
+// Initializes the configuration from environment variables.
+func NewConfigFromEnv() *Config {
+	c := &Config{}
+	_ = config.Load("STACK", c)
+
+	logrus.WithFields(config.Fields(c)).Debug("Stack Config initialized")
+
+	return c
+}
+
+// List of signals, configured as a comma separated list of signal names (e.g. "SIGINT,SIGTERM").
+type Signals []os.Signal
+
+// Parses a comma separated list of signal names (see ParseSignals()).
+func (s *Signals) UnmarshalText(text []byte) error {
+	parsed, err := ParseSignals(string(text))
+	if err != nil {
+		return err
+	}
+	*s = parsed
+	return nil
+}
+
+// Parses a comma separated list of signal names (e.g. "SIGINT,SIGTERM").
+func ParseSignals(names string) ([]os.Signal, error) {
+	parsed := make([]os.Signal, 0)
+	for _, name := range strings.Split(names, ",") {
+		name = strings.ToUpper(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !strings.HasPrefix(name, "SIG") {
+			name = "SIG" + name
+		}
+
+		sig, ok := signals[name]
+		if !ok {
+			return nil, fmt.Errorf("unsupported signal %s", name)
+		}
+		parsed = append(parsed, sig)
+	}
+	return parsed, nil
+}
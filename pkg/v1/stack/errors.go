@@ -0,0 +1,75 @@
+package stack
+
+import (
+	"fmt"
+	p "github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider"
+	"strings"
+)
+
+// Lifecycle phases in which a Provider can fail.
+const (
+	PhaseInit  = "init"
+	PhaseRun   = "run"
+	PhaseClose = "close"
+)
+
+// Error of a single Provider during one of its lifecycle phases.
+type ProviderError struct {
+	Provider p.Provider
+	Phase    string // One of PhaseInit, PhaseRun or PhaseClose.
+	Err      error
+}
+
+func newProviderError(provider p.Provider, phase string, err error) *ProviderError {
+	return &ProviderError{Provider: provider, Phase: phase, Err: err}
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("%s failed to %s: %s", p.Name(e.Provider), e.Phase, e.Err)
+}
+
+// Returns the original error, so errors.Is() and errors.As() can be used on a ProviderError.
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// Error returned by the Stack, containing the errors of every Provider that failed.
+type MultiError struct {
+	Errors []*ProviderError
+}
+
+// Adds the errors of another error to the MultiError.
+// Nil errors are ignored, ProviderErrors and MultiErrors are flattened and any other error is attributed to the given Provider.
+func (e *MultiError) add(provider p.Provider, phase string, err error) {
+	switch err := err.(type) {
+	case nil:
+	case *MultiError:
+		if err != nil {
+			e.Errors = append(e.Errors, err.Errors...)
+		}
+	case *ProviderError:
+		e.Errors = append(e.Errors, err)
+	default:
+		e.Errors = append(e.Errors, newProviderError(provider, phase, err))
+	}
+}
+
+// Returns nil if no errors were added, so the result can be returned as an error directly.
+func (e *MultiError) errorOrNil() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *MultiError) Error() string {
+	if len(e.Errors) == 1 {
+		return e.Errors[0].Error()
+	}
+
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%d provider errors occurred: %s", len(e.Errors), strings.Join(messages, "; "))
+}
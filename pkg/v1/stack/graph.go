@@ -0,0 +1,166 @@
+package stack
+
+import (
+	"fmt"
+	p "github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider"
+	"reflect"
+	"strings"
+)
+
+// Visiting states used while sorting the graph.
+const (
+	unvisited = iota
+	visiting
+	visited
+)
+
+// Dependency graph of all Providers known to the Stack.
+// Providers are kept in the order they were added, which is used as tie-breaker while sorting.
+type graph struct {
+	nodes []p.Provider
+	edges map[p.Provider][]p.Provider
+}
+
+// Creates an empty dependency graph.
+func newGraph() *graph {
+	return &graph{
+		nodes: make([]p.Provider, 0),
+		edges: make(map[p.Provider][]p.Provider),
+	}
+}
+
+// Adds a Provider and (recursively) all of its declared dependencies to the graph.
+// Adding a Provider that is already known has no effect.
+func (g *graph) add(provider p.Provider) {
+	if _, ok := g.edges[provider]; ok {
+		return
+	}
+
+	deps := dependencies(provider)
+	g.nodes = append(g.nodes, provider)
+	g.edges[provider] = deps
+	for _, dep := range deps {
+		g.add(dep)
+	}
+}
+
+// Updates the dependencies of a Provider that is already part of the graph, adding any new dependencies.
+func (g *graph) refresh(provider p.Provider) {
+	deps := dependencies(provider)
+	g.edges[provider] = deps
+	for _, dep := range deps {
+		g.add(dep)
+	}
+}
+
+// Returns all Providers in the graph in topological order.
+// Returns an error if a dependency cycle is found.
+func (g *graph) sortAll() ([]p.Provider, error) {
+	return g.sort(g.nodes...)
+}
+
+// Returns the given Providers and all of their (transitive) dependencies in topological order: every Provider comes after its dependencies.
+// Returns an error if a dependency cycle is found.
+func (g *graph) sort(roots ...p.Provider) ([]p.Provider, error) {
+	states := make(map[p.Provider]int, len(g.nodes))
+	sorted := make([]p.Provider, 0, len(g.nodes))
+	path := make([]p.Provider, 0)
+
+	var visit func(provider p.Provider) error
+	visit = func(provider p.Provider) error {
+		switch states[provider] {
+		case visited:
+			return nil
+		case visiting:
+			return newCycleError(append(path, provider))
+		}
+
+		states[provider] = visiting
+		path = append(path, provider)
+		for _, dep := range g.edges[provider] {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		states[provider] = visited
+
+		sorted = append(sorted, provider)
+		return nil
+	}
+
+	for _, root := range roots {
+		if err := visit(root); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
+
+// Returns all (transitive) dependencies of a Provider that are RunProviders.
+func (g *graph) runDependencies(provider p.Provider) []p.RunProvider {
+	deps, err := g.sort(g.edges[provider]...)
+	if err != nil {
+		// Cycles are detected before anything is launched.
+		return nil
+	}
+
+	runDeps := make([]p.RunProvider, 0)
+	for _, dep := range deps {
+		if runDep, ok := dep.(p.RunProvider); ok {
+			runDeps = append(runDeps, runDep)
+		}
+	}
+	return runDeps
+}
+
+// Returns the declared (non-nil) dependencies of a Provider.
+func dependencies(provider p.Provider) []p.Provider {
+	dependent, ok := provider.(p.DependentProvider)
+	if !ok {
+		return nil
+	}
+
+	deps := make([]p.Provider, 0)
+	for _, dep := range dependent.Dependencies() {
+		if isNil(dep) {
+			continue
+		}
+		deps = append(deps, dep)
+	}
+	return deps
+}
+
+// Returns true if the Provider is nil, or a typed nil pointer (e.g. an optional *probes.Probes).
+func isNil(provider p.Provider) bool {
+	if provider == nil {
+		return true
+	}
+	v := reflect.ValueOf(provider)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+// Error returned when the dependencies of the Providers form a cycle.
+type CycleError struct {
+	Path []p.Provider // The Providers forming the cycle, starting and ending with the same Provider.
+}
+
+func newCycleError(path []p.Provider) *CycleError {
+	// Only keep the part of the path that actually forms the cycle.
+	last := path[len(path)-1]
+	for i, provider := range path {
+		if provider == last {
+			path = path[i:]
+			break
+		}
+	}
+	return &CycleError{Path: append([]p.Provider(nil), path...)}
+}
+
+func (e *CycleError) Error() string {
+	names := make([]string, len(e.Path))
+	for i, provider := range e.Path {
+		names[i] = p.Name(provider)
+	}
+	return fmt.Sprintf("dependency cycle detected: %s", strings.Join(names, " -> "))
+}
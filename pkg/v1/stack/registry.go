@@ -0,0 +1,68 @@
+package stack
+
+import (
+	p "github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider"
+	"reflect"
+)
+
+// Looks up a Provider known to the Stack (added or initialized) by type, similar to errors.As().
+// Target must be a non-nil pointer to a Provider type or interface, e.g.:
+//
+//	var probesProvider *probes.Probes
+//	if st.Lookup(&probesProvider) { ... }
+//
+// If several Providers match, the first one that was added is used.
+// Returns false if no Provider matches, leaving target untouched (so optional dependencies stay nil).
+func (s *Stack) Lookup(target interface{}) bool {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		panic("stack: Lookup target must be a non-nil pointer")
+	}
+	targetType := value.Type().Elem()
+
+	for _, provider := range s.graph.nodes {
+		if reflect.TypeOf(provider).AssignableTo(targetType) {
+			value.Elem().Set(reflect.ValueOf(provider))
+			return true
+		}
+	}
+	return false
+}
+
+// Looks up a Provider (see Lookup()). Panics if no Provider matches.
+func (s *Stack) MustLookup(target interface{}) {
+	if !s.Lookup(target) {
+		s.logger.Panicf("No provider found for %s", reflect.TypeOf(target).Elem())
+	}
+}
+
+// Looks up a Provider known to the Stack by its name (see provider.Name()).
+// Returns nil if no Provider has the given name.
+func (s *Stack) LookupName(name string) p.Provider {
+	for _, provider := range s.graph.nodes {
+		if p.Name(provider) == name {
+			return provider
+		}
+	}
+	return nil
+}
+
+// Resolves all Providers implementing provider.Resolver that haven't been resolved yet,
+// after which their (possibly changed) dependencies are added to the graph.
+func (s *Stack) resolve() error {
+	// Resolving can add new Providers to the graph, which might need resolving as well.
+	for i := 0; i < len(s.graph.nodes); i++ {
+		provider := s.graph.nodes[i]
+		resolver, ok := provider.(p.Resolver)
+		if !ok || s.resolved[provider] {
+			continue
+		}
+
+		if err := resolver.Resolve(s); err != nil {
+			return newProviderError(provider, PhaseInit, err)
+		}
+		s.resolved[provider] = true
+		s.graph.refresh(provider)
+	}
+	return nil
+}
@@ -1,96 +1,509 @@
 package stack
 
 import (
+	"context"
+	"fmt"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
 	p "github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider"
 	lp "github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider/logrus"
 	"github.com/sirupsen/logrus"
 	"os"
 	"os/signal"
 	"sync"
+	"time"
 )
 
-var runOnce sync.Once
-var closeOnce sync.Once
+const (
+	// Maximum duration a RunProvider waits for its dependencies to run before being launched.
+	dependencyTimeout = 30 * time.Second
+)
+
+// Used to force the application to exit. Can be replaced in tests.
+var exit = os.Exit
 
 // Stack manages all providers.
+// Providers can declare their dependencies (see provider.DependentProvider), which the Stack uses to build a dependency graph.
+// Providers are initialized and launched in topological order (dependencies first) and closed in reverse order.
 type Stack struct {
+	Config *Config
+
 	logger    *logrus.Logger
-	providers []p.Provider
+	providers []p.Provider // Initialized providers, in order of initialization.
+	graph     *graph
+
+	initialized map[p.Provider]bool
+	resolved    map[p.Provider]bool
+	launched    map[p.Provider]chan struct{} // Closed once the Run() method of a RunProvider has returned.
+
+	runOnce   sync.Once // Makes sure the Stack isn't started twice.
+	closeOnce sync.Once // Makes sure the Stack isn't stopped twice.
+	child     *childProvider
+
+	mu       sync.Mutex
+	runErrs  MultiError    // Errors returned by the Run() methods of the RunProviders.
+	failed   chan struct{} // Closed as soon as any RunProvider fails.
+	failOnce sync.Once
+	stopping chan struct{} // Closed once the Stack starts shutting down.
+	stopOnce sync.Once
+
+	supervisions map[p.Provider]Supervision
+	statuses     map[p.Provider]*ProviderStatus
+	statusOrder  []p.Provider // Providers in order of their first status update.
 }
 
-// Creates a new Stack.
+// Creates a new Stack, configured using environment variables.
 func New() *Stack {
+	return NewWithConfig(NewConfigFromEnv())
+}
+
+// Creates a new Stack using the given configuration.
+func NewWithConfig(config *Config) *Stack {
 	return &Stack{
+		Config: config,
 		// Stack uses its own Logger, since it already logs before the Logrus Provider has been initialized.
-		logger:    lp.NewLogger(lp.ParseEnv()),
-		providers: make([]p.Provider, 0),
+		logger:      lp.NewLogger(lp.ParseEnv()),
+		providers:   make([]p.Provider, 0),
+		graph:       newGraph(),
+		initialized: make(map[p.Provider]bool),
+		resolved:    make(map[p.Provider]bool),
+		launched:    make(map[p.Provider]chan struct{}),
+		failed:      make(chan struct{}),
+		stopping:    make(chan struct{}),
+
+		supervisions: make(map[p.Provider]Supervision),
+		statuses:     make(map[p.Provider]*ProviderStatus),
+		statusOrder:  make([]p.Provider, 0),
 	}
 }
 
-// Initializes a given Provider. Panics on failure.
-func (s *Stack) MustInit(provider p.Provider) {
-	name := p.Name(provider)
-	s.logger.Debugf("%s initializing...", name)
+// Adds Providers to the Stack without initializing them yet.
+// This allows adding Providers in any order: they are initialized in topological order once Run() is called.
+func (s *Stack) Add(providers ...p.Provider) {
+	for _, provider := range providers {
+		s.graph.add(provider)
+	}
+}
+
+// Initializes a given Provider, after initializing any of its dependencies that haven't been initialized yet.
+// On failure, all Providers that were already initialized are closed again.
+// Returns a MultiError naming the failing Provider(s), or a *config.Error if the configuration of the Stack or of any Provider to initialize is invalid (see provider.Configured).
+func (s *Stack) Init(provider p.Provider) error {
+	return s.InitContext(context.Background(), provider)
+}
+
+// Initializes a given Provider (see Init()).
+// The context is passed on to Providers implementing provider.ContextInitializer.
+func (s *Stack) InitContext(ctx context.Context, provider p.Provider) error {
+	s.graph.add(provider)
+	if err := s.resolve(); err != nil {
+		return s.abort(provider, PhaseInit, err)
+	}
 
-	if err := provider.Init(); err != nil {
-		s.logger.WithError(err).Panicf("Error during %s initialization", name)
+	order, err := s.graph.sort(provider)
+	if err != nil {
+		return s.abort(provider, PhaseInit, err)
 	}
+	return s.init(ctx, order)
+}
 
-	s.providers = append(s.providers, provider)
-	s.logger.Infof("%s initialized", name)
+// Initializes a given Provider (see Init()). Panics on failure.
+func (s *Stack) MustInit(provider p.Provider) {
+	if err := s.Init(provider); err != nil {
+		s.logger.WithError(err).Panicf("Error during %s initialization", p.Name(provider))
+	}
 }
 
 // Loops through all Providers and runs all RunProvider instances.
-// If any run fails, will automatically close all providers and shut down the application.
-func (s *Stack) MustRun() {
-	// RunOnce makes sure the Stack isn't started twice.
-	runOnce.Do(func() {
-		for _, provider := range s.providers {
-			if runProvider, ok := provider.(p.RunProvider); ok {
-				go s.launch(runProvider)
-			}
-		}
-		s.handleInterrupt()
+// Providers that were added but not initialized yet are initialized first.
+// Each RunProvider is only launched once the RunProviders it depends on are running.
+// Blocks until the context is done, a shutdown signal is received or any RunProvider fails.
+// While running, changes to the configuration file and to rotated secret files are applied to the Providers implementing provider.Reconfigurable (see config.Watch()).
+// It then runs the graceful shutdown sequence (see provider.ShutdownListener and the DrainDelay configuration) and closes all Providers.
+// Returns a MultiError containing the errors of all Providers that failed to run or close.
+func (s *Stack) Run(ctx context.Context) error {
+	var err error
+	s.runOnce.Do(func() {
+		err = s.run(ctx)
 	})
+	return err
 }
 
-// Loops through all Providers (backwards) and closes all of them. Panics on failure.
-func (s *Stack) MustClose() {
-	// CloseOnce makes sure the Stack isn't stopped twice.
-	closeOnce.Do(func() {
+// Runs all RunProviders (see Run()) until the application is interrupted. Panics on failure.
+func (s *Stack) MustRun() {
+	if err := s.Run(context.Background()); err != nil {
+		s.logger.WithError(err).Panic("Error while running Stack")
+	}
+}
+
+// Loops through all Providers (backwards) and closes all of them.
+// Since Providers are initialized in topological order, Providers are always closed before their dependencies.
+// A failing Provider doesn't prevent the others from being closed.
+//
+// Closing all Providers takes at most the configured ShutdownTimeout (or until the context is done).
+// This budget is split evenly across the Providers implementing provider.ContextCloser that still need to be closed,
+// so time left unused by a Provider becomes available to the next ones.
+// Providers that don't finish closing within their share are abandoned, and the remaining Providers are no longer closed once the budget is spent.
+// Closing the Stack while it runs stops it: RunProviders returning because they are closed aren't restarted, and Run() returns.
+// Returns a MultiError containing the errors of all Providers that failed to close.
+func (s *Stack) Close(ctx context.Context) error {
+	s.setStopping()
+
+	errs := &MultiError{}
+	s.closeOnce.Do(func() {
+		if s.Config.ShutdownTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, s.Config.ShutdownTimeout)
+			defer cancel()
+		}
+
 		for i := len(s.providers) - 1; i >= 0; i-- {
-			name := p.Name(s.providers[i])
+			provider := s.providers[i]
+			if err := ctx.Err(); err != nil {
+				s.setClosed(provider, 0, err)
+				errs.add(provider, PhaseClose, err)
+				continue
+			}
+
+			name := p.Name(provider)
 			s.logger.Debugf(" %s closing...", name)
 
-			if err := s.providers[i].Close(); err != nil {
-				s.logger.WithError(err).Panicf("%s failed to close", name)
+			start := time.Now()
+			err := s.close(ctx, provider, s.providers[:i+1])
+			s.setClosed(provider, time.Since(start), err)
+			if err != nil {
+				s.logger.WithError(err).Errorf("%s failed to close", name)
+				errs.add(provider, PhaseClose, err)
+				continue
 			}
 
 			s.logger.Infof("%s closed", name)
 		}
 	})
+	return errs.errorOrNil()
+}
+
+// Closes all Providers (see Close()). Panics on failure.
+func (s *Stack) MustClose() {
+	if err := s.Close(context.Background()); err != nil {
+		s.logger.WithError(err).Panic("Error while closing Stack")
+	}
+}
+
+// Initializes and launches all Providers, then waits for the Stack to be stopped.
+func (s *Stack) run(ctx context.Context) error {
+	// Signals are already caught before launching, so the shutdown sequence also applies to Providers that are still starting up.
+	signals := make(chan os.Signal, 1)
+	if len(s.Config.ShutdownSignals) > 0 {
+		signal.Notify(signals, s.Config.ShutdownSignals...)
+		defer signal.Stop(signals)
+	}
+
+	if err := s.start(ctx); err != nil {
+		return err
+	}
+
+	// Configuration changes are applied until the shutdown sequence starts.
+	watchCtx, stopWatching := context.WithCancel(ctx)
+	go config.Watch(watchCtx, s.reconfigure)
+	failed := s.wait(ctx, signals)
+	stopWatching()
+	if s.isStopping() {
+		// The Stack was closed while running, its Providers are closed already.
+		return s.stop()
+	}
+
+	// A second signal during the shutdown sequence forces the application to exit immediately.
+	stopped := make(chan struct{})
+	defer close(stopped)
+	go func() {
+		select {
+		case sig := <-signals:
+			s.logger.Warnf("Received %s during shutdown, forcing exit", sig)
+			exit(1)
+		case <-stopped:
+		}
+	}()
+
+	s.drain(!failed)
+	return s.stop()
+}
+
+// Initializes all Providers that haven't been initialized yet, in topological order.
+func (s *Stack) initAll(ctx context.Context) error {
+	if err := s.resolve(); err != nil {
+		return s.abort(nil, PhaseInit, err)
+	}
+
+	order, err := s.graph.sortAll()
+	if err != nil {
+		return s.abort(err.(*CycleError).Path[0], PhaseInit, err)
+	}
+	return s.init(ctx, order)
 }
 
-// Launches a RunProvider.
+// Initializes all Providers and launches all RunProviders.
+func (s *Stack) start(ctx context.Context) error {
+	if err := s.initAll(ctx); err != nil {
+		return err
+	}
+
+	for _, provider := range s.providers {
+		if _, ok := provider.(p.RunProvider); ok {
+			s.launched[provider] = make(chan struct{})
+		}
+	}
+	for _, provider := range s.providers {
+		if runProvider, ok := provider.(p.RunProvider); ok {
+			go s.launch(runProvider)
+		}
+	}
+	return nil
+}
+
+// Closes all Providers after the Stack stopped running.
+// Returns the errors of all RunProviders that failed, together with the errors that occurred while closing.
+func (s *Stack) stop() error {
+	s.setStopping()
+
+	errs := &MultiError{}
+	errs.add(nil, PhaseClose, s.Close(context.Background()))
+	s.mu.Lock()
+	errs.Errors = append(append([]*ProviderError{}, s.runErrs.Errors...), errs.Errors...)
+	s.mu.Unlock()
+	return errs.errorOrNil()
+}
+
+// Initializes the given Providers in order, skipping those that have already been initialized.
+// Closes all initialized Providers on failure.
+func (s *Stack) init(ctx context.Context, providers []p.Provider) error {
+	if err := s.checkConfig(providers); err != nil {
+		return err
+	}
+
+	for _, provider := range providers {
+		if s.initialized[provider] {
+			continue
+		}
+
+		name := p.Name(provider)
+		s.logger.Debugf("%s initializing...", name)
+		s.setState(provider, StateInitializing, nil)
+
+		var err error
+		if initializer, ok := provider.(p.ContextInitializer); ok {
+			err = initializer.InitContext(ctx)
+		} else {
+			err = provider.Init()
+		}
+		if err != nil {
+			s.logger.WithError(err).Errorf("Error during %s initialization", name)
+			s.setState(provider, StateFailed, err)
+			return s.abort(provider, PhaseInit, err)
+		}
+
+		s.setState(provider, StateInitialized, nil)
+		s.initialized[provider] = true
+		s.providers = append(s.providers, provider)
+		s.logger.Infof("%s initialized", name)
+	}
+	return nil
+}
+
+// Checks the configuration of the Stack and of the Providers that haven't been initialized yet, returning a *config.Error listing all of their invalid settings.
+func (s *Stack) checkConfig(providers []p.Provider) error {
+	errs := []error{config.Err(s.Config)}
+	for _, provider := range providers {
+		if configured, ok := provider.(p.Configured); ok && !s.initialized[provider] {
+			errs = append(errs, configured.ConfigError())
+		}
+	}
+	return config.Merge(errs...)
+}
+
+// Closes a single Provider, within its share of the shutdown budget.
+// The remaining Providers (including the given one) are used to calculate that share.
+func (s *Stack) close(ctx context.Context, provider p.Provider, remaining []p.Provider) error {
+	closer, ok := provider.(p.ContextCloser)
+	if !ok {
+		return closeWithin(ctx, provider.Close)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		shares := 0
+		for _, other := range remaining {
+			if _, ok := other.(p.ContextCloser); ok {
+				shares++
+			}
+		}
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Until(deadline)/time.Duration(shares))
+		defer cancel()
+	}
+
+	return closeWithin(ctx, func() error {
+		return closer.CloseContext(ctx)
+	})
+}
+
+// Calls the close function, but stops waiting for it once the context is done.
+func closeWithin(ctx context.Context, closeFunc func() error) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- closeFunc()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("closing aborted: %w", ctx.Err())
+	}
+}
+
+// Closes all initialized Providers after a Provider failed.
+// Returns the original error, together with any errors that occurred while closing.
+func (s *Stack) abort(provider p.Provider, phase string, err error) error {
+	errs := &MultiError{}
+	errs.add(provider, phase, err)
+	errs.add(nil, PhaseClose, s.Close(context.Background()))
+	return errs
+}
+
+// Launches a RunProvider, once all of the RunProviders it depends on are running.
 // The run method of Provider is a blocking call, thus this method should be called in a separate routine.
+// The RunProvider is supervised (see Supervise()): errors (and panics) are recorded instead of killing the application.
 func (s *Stack) launch(provider p.RunProvider) {
-	name := p.Name(provider)
-	s.logger.Debugf("%s launching...", name)
+	defer close(s.launched[provider])
 
-	if err := provider.Run(); err != nil {
-		s.logger.WithError(err).Panicf("%s failed to run", name)
+	for _, dep := range s.graph.runDependencies(provider) {
+		if err := s.waitForDependency(dep); err != nil {
+			s.logger.WithError(err).Errorf("%s failed to run", p.Name(provider))
+			s.setState(provider, StateFailed, err)
+			setFailed(provider, err)
+			s.fail(provider, err)
+			return
+		}
 	}
+
+	s.supervise(provider)
 }
 
-// Handles any panic inside the MustRun() method by closing all providers.
-func (s *Stack) handleInterrupt() {
-	signalChan := make(chan os.Signal, 1)
-	cleanupDone := make(chan struct{})
-	signal.Notify(signalChan, os.Interrupt)
+// Records the error of a failed RunProvider and signals the Stack to stop.
+func (s *Stack) fail(provider p.RunProvider, err error) {
+	s.record(provider, err)
+
+	s.failOnce.Do(func() {
+		close(s.failed)
+	})
+}
+
+// Records the error of a failed RunProvider, which is returned once the Stack stops running.
+func (s *Stack) record(provider p.RunProvider, err error) {
+	s.mu.Lock()
+	s.runErrs.add(provider, PhaseRun, err)
+	s.mu.Unlock()
+}
+
+// Marks the Stack as shutting down, so the RunProviders that return while being closed aren't supervised any longer.
+func (s *Stack) setStopping() {
+	s.stopOnce.Do(func() {
+		close(s.stopping)
+	})
+}
+
+// Returns true once the Stack has started shutting down.
+func (s *Stack) isStopping() bool {
+	select {
+	case <-s.stopping:
+		return true
+	default:
+		return false
+	}
+}
+
+// Waits until a RunProvider is running, or returns an error once it failed.
+// A RunProvider whose Run() method returned without error (e.g. because it's disabled) is considered ready as well.
+func (s *Stack) waitForDependency(dep p.RunProvider) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dependencyTimeout)
+	defer cancel()
+	launched := s.launched[dep]
 	go func() {
-		<-signalChan
-		s.MustClose()
-		close(cleanupDone)
+		select {
+		case <-launched:
+			cancel()
+		case <-ctx.Done():
+		}
 	}()
-	<-cleanupDone
+
+	err := p.WaitReady(ctx, dep)
+	if err == nil {
+		return nil
+	}
+	select {
+	case <-launched:
+		if s.state(dep) != StateFailed {
+			return nil
+		}
+		// The context got cancelled as the dependency failed, so its error might not have been seen yet.
+		if readiness, ok := dep.(p.Readiness); ok && readiness.Err() != nil {
+			err = fmt.Errorf("%s failed: %w", p.Name(dep), readiness.Err())
+		} else {
+			err = fmt.Errorf("%s failed", p.Name(dep))
+		}
+	default:
+	}
+	return fmt.Errorf("waiting for dependency: %w", err)
+}
+
+// Blocks until the context is done, a shutdown signal is received, any RunProvider fails or the Stack is closed.
+// Returns true if the Stack stopped because a RunProvider failed.
+func (s *Stack) wait(ctx context.Context, signals <-chan os.Signal) bool {
+	select {
+	case <-ctx.Done():
+		s.logger.Info("Context done, shutting down")
+	case sig := <-signals:
+		s.logger.Infof("Received %s, shutting down", sig)
+	case <-s.failed:
+		s.logger.Error("Provider failed, shutting down")
+		return true
+	case <-s.stopping:
+		s.logger.Info("Stack closed, shutting down")
+	}
+	return false
+}
+
+// Runs the graceful shutdown sequence, before any Provider is closed:
+// Providers first stop attracting new traffic, then the drain delay passes (unless skipped), after which the Providers are notified they are about to be closed.
+func (s *Stack) drain(delay bool) {
+	s.notifyShutdown(p.ShutdownPhaseDrain)
+
+	if delay && s.Config.DrainDelay > 0 {
+		s.logger.Infof("Waiting %s for load balancers to deregister the application...", s.Config.DrainDelay)
+		time.Sleep(s.Config.DrainDelay)
+	}
+
+	s.notifyShutdown(p.ShutdownPhaseStop)
+}
+
+// Asks all initialized Reconfigurable Providers to apply the changed configuration.
+func (s *Stack) reconfigure() {
+	for _, provider := range s.providers {
+		if reconfigurable, ok := provider.(p.Reconfigurable); ok {
+			if err := reconfigurable.Reconfigure(); err != nil {
+				s.logger.WithError(err).Errorf("Rejected the new configuration of %s, keeping the current one", p.Name(provider))
+				continue
+			}
+			s.logger.Infof("Applied the new configuration to %s", p.Name(provider))
+		}
+	}
+}
+
+// Notifies all initialized ShutdownListeners of a shutdown phase.
+func (s *Stack) notifyShutdown(phase p.ShutdownPhase) {
+	for _, provider := range s.providers {
+		if listener, ok := provider.(p.ShutdownListener); ok {
+			listener.OnShutdown(phase)
+		}
+	}
 }
@@ -1,13 +1,19 @@
 package stack
 
 import (
+	"context"
 	"errors"
 	"github.azc.ext.hp.com/hp-business-platform/lib-core-go/pkg/v1/test"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
 	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/sirupsen/logrus"
+	"os"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -19,12 +25,6 @@ func TestStack(t *testing.T) {
 
 var _ = Describe("Stack", func() {
 
-	BeforeEach(func() {
-		// Make sure that the stack can be used multiple times (once per test).
-		closeOnce = sync.Once{}
-		runOnce = sync.Once{}
-	})
-
 	Describe("Provider flow", func() {
 		Context("Default providers", func() {
 			st := New()
@@ -90,21 +90,66 @@ var _ = Describe("Stack", func() {
 			Expect(p1.initialized).To(BeTrue())
 			Expect(p2.initialized).To(BeFalse())
 		})
-		/* TODO(Peter): For some reason, the panic thrown during st.MustRun isn't being caught, which causes the whole test suite to stop due to this test.
-		It("Should stop the application if provider running fails", func() {
+		It("Should close all initialized providers if provider initialization fails", func() {
+			st := New()
+			p1, p2 := &MockedProvider1{}, &MockedProviderInitErr{}
+
+			Expect(st.Init(p1)).To(Succeed())
+			err := st.Init(p2)
+			Expect(err).To(BeAssignableToTypeOf(&MultiError{}))
+			Expect(err.(*MultiError).Errors).To(HaveLen(1))
+			Expect(err.(*MultiError).Errors[0].Provider).To(Equal(p2))
+			Expect(err.(*MultiError).Errors[0].Phase).To(Equal(PhaseInit))
+			Expect(err.Error()).To(Equal("stack.MockedProviderInitErr failed to init: init failed"))
+			Expect(p1.closed).To(BeTrue())
+		})
+		It("Should close all providers and return an error if provider running fails", func() {
 			st := New()
 			p1, p2, p3 := &MockedProvider1{}, &MockedRunProvider1{}, &MockedRunProviderRunErr{}
 
-			st.MustInit(p1)
-			st.MustInit(p2)
-			st.MustInit(p3)
-			Expect(st.providers).To(ConsistOf(p1, p2, p3))
-			Expect(st.MustRun).To(Panic())
+			st.Add(p1, p2, p3)
+			err := st.Run(context.Background())
+			Expect(err).To(BeAssignableToTypeOf(&MultiError{}))
+			Expect(err.(*MultiError).Errors).To(HaveLen(1))
+			Expect(err.(*MultiError).Errors[0].Provider).To(Equal(p3))
+			Expect(err.(*MultiError).Errors[0].Phase).To(Equal(PhaseRun))
+			Expect(p1.closed).To(BeTrue(), "Expected p1 to be closed if running p3 fails")
 			Expect(p2.IsRunning()).To(BeFalse(), "Expected p2 to be closed if running p3 fails")
 			Expect(p2.closed).To(BeTrue(), "Expected p2 to be closed if running p3 fails")
 			Expect(p3.IsRunning()).To(BeFalse(), "Expected p3 to not be started if running it fails")
 			Expect(p3.closed).To(BeTrue(), "Expected p3 to be closed if running it fails")
-		})*/
+		})
+		It("Should close all providers once the context is done", func() {
+			st := New()
+			p1 := &MockedRunProvider1{}
+
+			st.Add(p1)
+			ctx, cancel := context.WithCancel(context.Background())
+			go func() {
+				defer GinkgoRecover()
+				Eventually(p1.IsRunning).Should(BeTrue())
+				cancel()
+			}()
+			Expect(st.Run(ctx)).To(Succeed())
+			Expect(p1.closed).To(BeTrue())
+		})
+		It("Should close all providers and aggregate errors if provider closing fails", func() {
+			st := New()
+			p1, p2, p3, p4 := &MockedProvider1{}, &MockedProviderCloseErr{}, &MockedRunProvider1{}, &MockedProviderCloseErr{}
+
+			Expect(st.Init(p1)).To(Succeed())
+			Expect(st.Init(p2)).To(Succeed())
+			Expect(st.Init(p3)).To(Succeed())
+			Expect(st.Init(p4)).To(Succeed())
+			err := st.Close(context.Background())
+			Expect(err).To(BeAssignableToTypeOf(&MultiError{}))
+			Expect(err.(*MultiError).Errors).To(HaveLen(2))
+			Expect(err.(*MultiError).Errors[0].Provider).To(Equal(p4))
+			Expect(err.(*MultiError).Errors[1].Provider).To(Equal(p2))
+			Expect(errors.Is(err.(*MultiError).Errors[0], errClose)).To(BeTrue())
+			Expect(p1.closed).To(BeTrue())
+			Expect(p3.closed).To(BeTrue())
+		})
 		It("Should panic if provider closing fails", func() {
 			st := New()
 			p1, p2, p3 := &MockedProvider1{}, &MockedRunProvider1{}, &MockedProviderCloseErr{}
@@ -114,12 +159,485 @@ var _ = Describe("Stack", func() {
 			st.MustInit(p3)
 			Expect(st.providers).To(ConsistOf(p1, p2, p3))
 			Expect(st.MustClose).To(Panic())
-			Expect(p1.closed).To(BeFalse(), "Expected p3 to be closed first, so p1 should not be closed yet")
-			Expect(p2.closed).To(BeFalse(), "Expected p3 to be closed first, so p2 should not be closed yet")
+			Expect(p1.closed).To(BeTrue(), "Expected p1 to be closed, even though closing p3 failed")
+			Expect(p2.closed).To(BeTrue(), "Expected p2 to be closed, even though closing p3 failed")
 			Expect(p3.closed).To(BeFalse())
 		})
 	})
 
+	Describe("Dependency graph", func() {
+		It("Should initialize dependencies first and close them last", func() {
+			st := New()
+			p1, p2 := &MockedProvider1{}, &MockedProvider2{}
+			p3 := &MockedDependentProvider{deps: []provider.Provider{p1, p2}}
+
+			st.MustInit(p3)
+			Expect(st.providers).To(Equal([]provider.Provider{p1, p2, p3}))
+			Expect(p1.initialized).To(BeTrue())
+			Expect(p2.initialized).To(BeTrue())
+
+			st.MustInit(p1)
+			Expect(st.providers).To(HaveLen(3), "Providers should only be initialized once")
+		})
+		It("Should initialize added providers in topological order when running", func() {
+			st := New()
+			p1, p2 := &MockedRunProvider1{}, &MockedProvider1{}
+			p3 := &MockedDependentProvider{deps: []provider.Provider{p2, p1}}
+
+			st.Add(p3, p1)
+			Expect(st.providers).To(HaveLen(0))
+			go st.MustRun()
+			Eventually(p1.IsRunning).Should(BeTrue())
+			Expect(st.providers).To(Equal([]provider.Provider{p2, p1, p3}))
+
+			st.MustClose()
+			Expect(p1.closed).To(BeTrue())
+			Expect(p2.closed).To(BeTrue())
+			Expect(p3.closed).To(BeTrue())
+		})
+		It("Should fail a run provider as soon as its dependency failed", func() {
+			st := New()
+			p1 := &MockedRunProviderRunErr{}
+			p2 := &MockedDependentRunProvider{deps: []provider.Provider{p1}}
+
+			st.Add(p2)
+			Expect(st.Supervise(p1, Supervision{Policy: RestartNever})).To(Succeed())
+			start := time.Now()
+			err := st.Run(context.Background())
+			Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+			Expect(err).To(HaveOccurred())
+			Expect(err.(*MultiError).Errors).To(HaveLen(2))
+			Expect(err.(*MultiError).Errors[1].Provider).To(Equal(p2))
+			Expect(errors.Is(err.(*MultiError).Errors[1], errRun)).To(BeTrue())
+			Expect(p2.Err()).To(HaveOccurred())
+			Expect(p2.IsRunning()).To(BeFalse())
+		})
+		It("Should ignore nil dependencies", func() {
+			st := New()
+			var optional *MockedProvider1
+			p1 := &MockedDependentProvider{deps: []provider.Provider{nil, optional}}
+
+			st.MustInit(p1)
+			Expect(st.providers).To(Equal([]provider.Provider{p1}))
+		})
+		It("Should panic if the dependencies contain a cycle", func() {
+			st := New()
+			p1, p2 := &MockedDependentProvider{}, &MockedDependentProvider{}
+			p1.deps = []provider.Provider{p2}
+			p2.deps = []provider.Provider{p1}
+
+			Expect(func() { st.MustInit(p1) }).To(Panic())
+			Expect(p1.initialized).To(BeFalse())
+			Expect(p2.initialized).To(BeFalse())
+
+			_, err := st.graph.sort(p1)
+			Expect(err).To(BeAssignableToTypeOf(&CycleError{}))
+			Expect(err.(*CycleError).Path).To(Equal([]provider.Provider{p1, p2, p1}))
+		})
+	})
+
+	Describe("Context-aware lifecycle", func() {
+		It("Should initialize and close context providers using their context", func() {
+			st := New()
+			p1 := &MockedContextProvider{}
+
+			type key struct{}
+			ctx := context.WithValue(context.Background(), key{}, "value")
+			Expect(st.InitContext(ctx, p1)).To(Succeed())
+			Expect(p1.initialized).To(BeTrue())
+			Expect(p1.initCtx.Value(key{})).To(Equal("value"))
+
+			Expect(st.Close(ctx)).To(Succeed())
+			Expect(p1.closed).To(BeTrue())
+			Expect(p1.closeCtx.Value(key{})).To(Equal("value"))
+			_, ok := p1.closeCtx.Deadline()
+			Expect(ok).To(BeTrue(), "Expected the close context to have a deadline")
+		})
+		It("Should split the shutdown budget across the context providers", func() {
+			st := NewWithConfig(&Config{ShutdownTimeout: 200 * time.Millisecond})
+			p1, p2, p3 := &MockedContextProvider{}, &MockedProvider1{}, &MockedContextProvider{}
+
+			Expect(st.Init(p1)).To(Succeed())
+			Expect(st.Init(p2)).To(Succeed())
+			Expect(st.Init(p3)).To(Succeed())
+			start := time.Now()
+			Expect(st.Close(context.Background())).To(Succeed())
+
+			deadline, _ := p3.closeCtx.Deadline()
+			Expect(deadline.Sub(start)).To(BeNumerically("~", 100*time.Millisecond, 20*time.Millisecond))
+			deadline, _ = p1.closeCtx.Deadline()
+			Expect(deadline.Sub(start)).To(BeNumerically("~", 200*time.Millisecond, 20*time.Millisecond))
+			Expect(p2.closed).To(BeTrue())
+		})
+		It("Should stop waiting for providers that don't close in time", func() {
+			st := NewWithConfig(&Config{ShutdownTimeout: 50 * time.Millisecond})
+			p1, p2 := &MockedProvider1{}, &MockedProviderCloseHang{}
+
+			Expect(st.Init(p1)).To(Succeed())
+			Expect(st.Init(p2)).To(Succeed())
+			err := st.Close(context.Background())
+			Expect(err).To(HaveOccurred())
+			Expect(err.(*MultiError).Errors).To(HaveLen(2))
+			Expect(err.(*MultiError).Errors[0].Provider).To(Equal(p2))
+			Expect(errors.Is(err.(*MultiError).Errors[0], context.DeadlineExceeded)).To(BeTrue())
+			Expect(err.(*MultiError).Errors[1].Provider).To(Equal(p1))
+			Expect(p1.closed).To(BeFalse(), "Expected p1 not to be closed once the shutdown budget is spent")
+		})
+	})
+
+	Describe("Shutdown sequence", func() {
+		var exitCodes chan int
+
+		BeforeEach(func() {
+			exitCodes = make(chan int, 1)
+			exit = func(code int) {
+				exitCodes <- code
+			}
+		})
+		AfterEach(func() {
+			exit = os.Exit
+		})
+
+		It("Should drain and close all providers when receiving a shutdown signal", func() {
+			st := NewWithConfig(&Config{
+				ShutdownTimeout: time.Second,
+				ShutdownSignals: []os.Signal{syscall.SIGUSR2},
+				DrainDelay:      50 * time.Millisecond,
+			})
+			p1 := &MockedShutdownListener{}
+
+			st.Add(p1)
+			done := make(chan error)
+			go func() {
+				done <- st.Run(context.Background())
+			}()
+			Eventually(p1.IsRunning).Should(BeTrue())
+
+			Expect(syscall.Kill(syscall.Getpid(), syscall.SIGUSR2)).To(Succeed())
+			Eventually(done).Should(Receive(BeNil()))
+			Expect(p1.phases).To(Equal([]provider.ShutdownPhase{provider.ShutdownPhaseDrain, provider.ShutdownPhaseStop}))
+			Expect(p1.stopped.Sub(p1.draining)).To(BeNumerically(">=", 50*time.Millisecond))
+			Expect(p1.closed).To(BeTrue())
+			Expect(exitCodes).ToNot(Receive())
+		})
+		It("Should force the application to exit when receiving a second signal", func() {
+			st := NewWithConfig(&Config{
+				ShutdownTimeout: time.Second,
+				ShutdownSignals: []os.Signal{syscall.SIGUSR2},
+				DrainDelay:      time.Second,
+			})
+			p1 := &MockedShutdownListener{}
+
+			st.Add(p1)
+			go func() {
+				_ = st.Run(context.Background())
+			}()
+			Eventually(p1.IsRunning).Should(BeTrue())
+
+			Expect(syscall.Kill(syscall.Getpid(), syscall.SIGUSR2)).To(Succeed())
+			Eventually(func() int { return len(p1.getPhases()) }).Should(Equal(1))
+			Expect(syscall.Kill(syscall.Getpid(), syscall.SIGUSR2)).To(Succeed())
+			Eventually(exitCodes).Should(Receive(Equal(1)))
+		})
+		It("Should parse signal names", func() {
+			parsed, err := ParseSignals("SIGINT, term,")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(parsed).To(Equal([]os.Signal{syscall.SIGINT, syscall.SIGTERM}))
+
+			_, err = ParseSignals("SIGKILL")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Supervision", func() {
+		It("Should restart a failing provider until it runs", func() {
+			st := New()
+			p1 := &MockedFlakyRunProvider{failures: 2}
+			name := provider.Name(p1)
+			crashes, restarts := testutil.ToFloat64(crashesCounter.WithLabelValues(name)), testutil.ToFloat64(restartsCounter.WithLabelValues(name))
+
+			st.Add(p1)
+			Expect(st.Supervise(p1, Supervision{Policy: RestartOnFailure, InitialBackoff: time.Millisecond, Critical: true})).To(Succeed())
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error)
+			go func() {
+				done <- st.Run(ctx)
+			}()
+
+			Eventually(p1.IsRunning).Should(BeTrue())
+			Expect(atomic.LoadInt32(&p1.runs)).To(BeEquivalentTo(3))
+			Expect(atomic.LoadInt32(&p1.restarts)).To(BeEquivalentTo(2))
+			Expect(testutil.ToFloat64(crashesCounter.WithLabelValues(name)) - crashes).To(BeEquivalentTo(2))
+			Expect(testutil.ToFloat64(restartsCounter.WithLabelValues(name)) - restarts).To(BeEquivalentTo(2))
+
+			cancel()
+			Eventually(done).Should(Receive(BeNil()))
+		})
+		It("Should stop the stack once a critical provider exceeds its maximum restarts", func() {
+			st := New()
+			p1, p2 := &MockedFlakyRunProvider{failures: 10}, &MockedRunProvider1{}
+
+			st.Add(p1, p2)
+			Expect(st.Supervise(p1, Supervision{Policy: RestartAlways, MaxRestarts: 2, InitialBackoff: time.Millisecond, Critical: true})).To(Succeed())
+			err := st.Run(context.Background())
+			Expect(err).To(HaveOccurred())
+			Expect(err.(*MultiError).Errors).To(HaveLen(1))
+			Expect(err.(*MultiError).Errors[0].Provider).To(Equal(p1))
+			Expect(err.Error()).To(ContainSubstring("giving up after 2 restarts: run failed"))
+			Expect(atomic.LoadInt32(&p1.runs)).To(BeEquivalentTo(3))
+			Expect(p2.closed).To(BeTrue())
+		})
+		It("Should keep the stack running if a non-critical provider stops", func() {
+			st := New()
+			p1, p2 := &MockedFlakyRunProvider{failures: 1}, &MockedRunProvider1{}
+
+			st.Add(p1, p2)
+			Expect(st.Supervise(p1, Supervision{Policy: RestartNever})).To(Succeed())
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error)
+			go func() {
+				done <- st.Run(ctx)
+			}()
+
+			Consistently(done, 50*time.Millisecond).ShouldNot(Receive())
+			Expect(atomic.LoadInt32(&p1.runs)).To(BeEquivalentTo(1))
+
+			cancel()
+			var err error
+			Eventually(done).Should(Receive(&err))
+			Expect(err).To(HaveOccurred())
+			Expect(err.(*MultiError).Errors[0].Provider).To(Equal(p1))
+		})
+		It("Should only restart restartable providers", func() {
+			st := New()
+			p1 := &MockedRunProviderRunErr{}
+
+			st.Add(p1)
+			Expect(st.Supervise(p1, Supervision{Policy: RestartOnFailure})).ToNot(Succeed())
+			Expect(st.Supervise(p1, Supervision{Policy: RestartAlways})).ToNot(Succeed())
+			Expect(st.Supervise(p1, Supervision{Policy: RestartNever})).To(Succeed())
+		})
+		It("Should stop the stack once a critical provider stops running", func() {
+			st := New()
+			p1, p2 := &MockedStoppingRunProvider{}, &MockedRunProvider1{}
+			name := provider.Name(p1)
+			stops := testutil.ToFloat64(stopsCounter.WithLabelValues(name))
+
+			st.Add(p1, p2)
+			err := st.Run(context.Background())
+			Expect(err).To(HaveOccurred())
+			Expect(err.(*MultiError).Errors).To(HaveLen(1))
+			Expect(err.(*MultiError).Errors[0].Provider).To(Equal(p1))
+			Expect(errors.Is(err.(*MultiError).Errors[0], ErrStopped)).To(BeTrue())
+			Expect(testutil.ToFloat64(stopsCounter.WithLabelValues(name)) - stops).To(BeEquivalentTo(1))
+			Expect(p2.closed).To(BeTrue())
+		})
+		It("Should not consider providers closed with the stack as stopped", func() {
+			st := New()
+			p1 := &MockedBlockingRunProvider{}
+			name := provider.Name(p1)
+			stops := testutil.ToFloat64(stopsCounter.WithLabelValues(name))
+
+			st.Add(p1)
+			errs := make(chan error, 1)
+			go func() {
+				errs <- st.Run(context.Background())
+			}()
+			Expect(provider.WaitReady(context.Background(), p1)).To(Succeed())
+			Expect(st.Close(context.Background())).To(Succeed())
+			Eventually(errs).Should(Receive(BeNil()))
+			Expect(testutil.ToFloat64(stopsCounter.WithLabelValues(name)) - stops).To(BeEquivalentTo(0))
+			Expect(p1.closed).To(BeTrue())
+		})
+		It("Should turn a panicking provider into an error", func() {
+			st := New()
+			p1 := &MockedFlakyRunProvider{failures: 1, panics: true}
+
+			st.Add(p1)
+			err := st.Run(context.Background())
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("stack.MockedFlakyRunProvider failed to run: panic: run panicked"))
+		})
+		It("Should increase the backoff exponentially", func() {
+			supervision := Supervision{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+			Expect(supervision.backoff(0)).To(Equal(time.Second))
+			Expect(supervision.backoff(1)).To(Equal(2 * time.Second))
+			Expect(supervision.backoff(2)).To(Equal(4 * time.Second))
+			Expect(supervision.backoff(3)).To(Equal(5 * time.Second))
+			Expect(Supervision{}.backoff(100)).To(Equal(defaultMaxBackoff))
+		})
+	})
+
+	Describe("Status", func() {
+		It("Should record the lifecycle state of every provider", func() {
+			st := New()
+			p1, p2, p3 := &MockedProvider1{}, &MockedRunProvider1{}, &MockedRunProviderRunErr{}
+
+			Expect(st.Init(p1)).To(Succeed())
+			Expect(st.Init(p2)).To(Succeed())
+			status := st.Status()
+			Expect(status).To(HaveLen(2))
+			Expect(status[0].Name).To(Equal("stack.MockedProvider1"))
+			Expect(status[0].State).To(Equal(StateInitialized))
+			Expect(status[1].State).To(Equal(StateInitialized))
+			Expect(testutil.ToFloat64(upGauge.WithLabelValues("stack.MockedProvider1"))).To(BeEquivalentTo(1))
+			Expect(testutil.ToFloat64(upGauge.WithLabelValues("stack.MockedRunProvider1"))).To(BeEquivalentTo(0))
+
+			st.Add(p3)
+			Expect(st.Run(context.Background())).ToNot(Succeed())
+			status = st.Status()
+			Expect(status).To(HaveLen(3))
+			Expect(status[0].State).To(Equal(StateClosed))
+			Expect(status[1].State).To(Equal(StateClosed))
+			Expect(status[2].Name).To(Equal("stack.MockedRunProviderRunErr"))
+			Expect(status[2].State).To(Equal(StateClosed))
+			Expect(status[2].Error).To(Equal("run failed"))
+			Expect(testutil.ToFloat64(upGauge.WithLabelValues("stack.MockedProvider1"))).To(BeEquivalentTo(0))
+		})
+		It("Should report running providers", func() {
+			st := New()
+			p1 := &MockedRunProvider2{}
+
+			st.Add(p1)
+			go st.MustRun()
+			Eventually(func() State {
+				status := st.Status()
+				if len(status) == 0 {
+					return ""
+				}
+				return status[0].State
+			}).Should(Equal(StateRunning))
+			Expect(testutil.ToFloat64(upGauge.WithLabelValues("stack.MockedRunProvider2"))).To(BeEquivalentTo(1))
+			st.MustClose()
+		})
+	})
+
+	Describe("Multiple stacks", func() {
+		It("Should run and close independent stacks", func() {
+			st1, st2 := New(), New()
+			p1, p2 := &MockedRunProvider1{}, &MockedRunProvider1{}
+
+			st1.Add(p1)
+			st2.Add(p2)
+			ctx1, cancel1 := context.WithCancel(context.Background())
+			ctx2, cancel2 := context.WithCancel(context.Background())
+			done1, done2 := make(chan error), make(chan error)
+			go func() { done1 <- st1.Run(ctx1) }()
+			go func() { done2 <- st2.Run(ctx2) }()
+			Eventually(p1.IsRunning).Should(BeTrue())
+			Eventually(p2.IsRunning).Should(BeTrue())
+
+			cancel1()
+			Eventually(done1).Should(Receive(BeNil()))
+			Expect(p1.closed).To(BeTrue())
+			Expect(p2.closed).To(BeFalse())
+
+			cancel2()
+			Eventually(done2).Should(Receive(BeNil()))
+			Expect(p2.closed).To(BeTrue())
+		})
+		It("Should run and close a child stack as part of its parent", func() {
+			parent, child := New(), New()
+			p1, p2, p3 := &MockedProvider1{}, &MockedShutdownListener{}, &MockedRunProvider1{}
+
+			child.Add(p1, p2)
+			parent.Add(child.AsProvider(), p3)
+			Expect(child.AsProvider()).To(BeIdenticalTo(child.AsProvider()))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error)
+			go func() { done <- parent.Run(ctx) }()
+			Eventually(child.AsProvider().IsRunning).Should(BeTrue())
+			Expect(p1.initialized).To(BeTrue())
+			Expect(p2.IsRunning()).To(BeTrue())
+			Expect(p3.IsRunning()).To(BeTrue())
+
+			cancel()
+			Eventually(done).Should(Receive(BeNil()))
+			Expect(p2.getPhases()).To(Equal([]provider.ShutdownPhase{provider.ShutdownPhaseDrain, provider.ShutdownPhaseStop}))
+			Expect(p1.closed).To(BeTrue())
+			Expect(p2.closed).To(BeTrue())
+			Expect(p3.closed).To(BeTrue())
+			Expect(child.AsProvider().IsRunning()).To(BeFalse())
+		})
+		It("Should only refuse to initialize the stack with an invalid configuration", func() {
+			st1, st2 := New(), New()
+			p1 := &MockedConfiguredProvider{err: &config.Error{Errors: []*config.FieldError{{Env: "MOCKED_PORT", Value: "70000", Err: errors.New("above the maximum of 65535")}}}}
+			p2 := &MockedProvider1{}
+
+			st1.Add(p1)
+			st2.Add(p2)
+			err := st1.Run(context.Background())
+			Expect(err).To(MatchError(`invalid configuration: MOCKED_PORT="70000": above the maximum of 65535`))
+			Expect(p1.initialized).To(BeFalse())
+			Expect(st2.Init(p2)).To(Succeed())
+			Expect(p2.initialized).To(BeTrue())
+		})
+		It("Should stop the parent stack if a provider of the child stack fails", func() {
+			parent, child := New(), New()
+			p1, p2 := &MockedRunProviderRunErr{}, &MockedRunProvider1{}
+
+			child.Add(p1)
+			parent.Add(child.AsProvider(), p2)
+			err := parent.Run(context.Background())
+			Expect(err).To(HaveOccurred())
+			Expect(err.(*MultiError).Errors).To(HaveLen(1))
+			Expect(err.(*MultiError).Errors[0].Provider).To(Equal(p1))
+			Expect(p1.closed).To(BeTrue())
+			Expect(p2.closed).To(BeTrue())
+		})
+	})
+
+	Describe("Registry", func() {
+		It("Should look up providers by type and name", func() {
+			st := New()
+			p1, p2 := &MockedProvider1{}, &MockedRunProvider1{}
+			st.Add(p1, p2)
+
+			var found1 *MockedProvider1
+			Expect(st.Lookup(&found1)).To(BeTrue())
+			Expect(found1).To(BeIdenticalTo(p1))
+
+			var found2 provider.RunProvider
+			Expect(st.Lookup(&found2)).To(BeTrue())
+			Expect(found2).To(BeIdenticalTo(p2))
+
+			var missing *MockedProvider2
+			Expect(st.Lookup(&missing)).To(BeFalse())
+			Expect(missing).To(BeNil())
+			Expect(func() { st.MustLookup(&missing) }).To(Panic())
+			Expect(func() { st.Lookup(missing) }).To(Panic())
+
+			Expect(st.LookupName("stack.MockedRunProvider1")).To(BeIdenticalTo(p2))
+			Expect(st.LookupName("stack.MockedProvider2")).To(BeNil())
+		})
+		It("Should resolve dependencies before initializing providers", func() {
+			st := New()
+			p1, p2 := &MockedResolverProvider{}, &MockedProvider1{}
+
+			st.Add(p1, p2)
+			Expect(st.initAll(context.Background())).To(Succeed())
+			Expect(p1.dep).To(BeIdenticalTo(p2))
+			Expect(st.providers).To(Equal([]provider.Provider{p2, p1}), "Expected the resolved dependency to be initialized first")
+		})
+		It("Should leave optional dependencies nil if absent", func() {
+			st := New()
+			p1 := &MockedResolverProvider{}
+
+			Expect(st.Init(p1)).To(Succeed())
+			Expect(p1.dep).To(BeNil())
+		})
+		It("Should fail if a required dependency is absent", func() {
+			st := New()
+			p1 := &MockedResolverProvider{required: true}
+
+			err := st.Init(p1)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("stack.MockedResolverProvider failed to init: missing dependency"))
+			Expect(p1.initialized).To(BeFalse())
+		})
+	})
+
 	Describe("Edge cases", func() {
 		Context("Double running or closing", func() {
 			st := New()
@@ -134,8 +652,6 @@ var _ = Describe("Stack", func() {
 				})
 				By("Adding a second provider and trying to run the stack again", func() {
 					st.MustInit(p2)
-					go st.handleInterrupt()
-					time.Sleep(1 * time.Millisecond)
 					go st.MustRun()
 					time.Sleep(1 * time.Millisecond)
 					Expect(p2.IsRunning()).To(BeFalse(), "The second provider should not be started if the stack is already running")
@@ -182,6 +698,44 @@ type MockedProvider2 struct {
 	AbstractMockedProvider
 }
 
+// Mocked provider that depends on other providers.
+type MockedDependentProvider struct {
+	AbstractMockedProvider
+	deps []provider.Provider
+}
+
+func (p *MockedDependentProvider) Dependencies() []provider.Provider {
+	return p.deps
+}
+
+// Mocked provider that resolves its dependency using the stack.
+type MockedResolverProvider struct {
+	AbstractMockedProvider
+	dep      *MockedProvider1
+	required bool
+}
+
+func (p *MockedResolverProvider) Resolve(registry provider.Registry) error {
+	if !registry.Lookup(&p.dep) && p.required {
+		return errors.New("missing dependency")
+	}
+	return nil
+}
+
+func (p *MockedResolverProvider) Dependencies() []provider.Provider {
+	return []provider.Provider{p.dep}
+}
+
+// Mocked provider reporting the errors of its configuration.
+type MockedConfiguredProvider struct {
+	AbstractMockedProvider
+	err error
+}
+
+func (p *MockedConfiguredProvider) ConfigError() error {
+	return p.err
+}
+
 // Mocked provider that throws an error while initializing.
 type MockedProviderInitErr struct {
 	AbstractMockedProvider
@@ -196,8 +750,36 @@ type MockedProviderCloseErr struct {
 	AbstractMockedProvider
 }
 
+var errClose = errors.New("close failed")
+
 func (p *MockedProviderCloseErr) Close() error {
-	return errors.New("close failed")
+	return errClose
+}
+
+// Mocked provider that never finishes closing.
+type MockedProviderCloseHang struct {
+	AbstractMockedProvider
+}
+
+func (p *MockedProviderCloseHang) Close() error {
+	select {}
+}
+
+// Mocked provider with a context-aware lifecycle, keeping track of the contexts it received.
+type MockedContextProvider struct {
+	AbstractMockedProvider
+	initCtx  context.Context
+	closeCtx context.Context
+}
+
+func (p *MockedContextProvider) InitContext(ctx context.Context) error {
+	p.initCtx = ctx
+	return p.Init()
+}
+
+func (p *MockedContextProvider) CloseContext(ctx context.Context) error {
+	p.closeCtx = ctx
+	return p.Close()
 }
 
 // Mocked run provider with some extra booleans to check its status.
@@ -231,13 +813,95 @@ type MockedRunProvider2 struct {
 	AbstractMockedRunProvider
 }
 
+// Mocked run provider keeping track of the shutdown phases it was notified of.
+type MockedShutdownListener struct {
+	AbstractMockedRunProvider
+	mu       sync.Mutex
+	phases   []provider.ShutdownPhase
+	draining time.Time
+	stopped  time.Time
+}
+
+func (p *MockedShutdownListener) OnShutdown(phase provider.ShutdownPhase) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.phases = append(p.phases, phase)
+	if phase == provider.ShutdownPhaseDrain {
+		p.draining = time.Now()
+	} else {
+		p.stopped = time.Now()
+	}
+}
+
+func (p *MockedShutdownListener) getPhases() []provider.ShutdownPhase {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.phases
+}
+
+// Mocked run provider that fails (or panics) a number of times before it runs.
+type MockedFlakyRunProvider struct {
+	AbstractMockedRunProvider
+	failures int
+	panics   bool
+	runs     int32
+	restarts int32
+}
+
+func (p *MockedFlakyRunProvider) Restart() error {
+	atomic.AddInt32(&p.restarts, 1)
+	return nil
+}
+
+func (p *MockedFlakyRunProvider) Run() error {
+	if int(atomic.AddInt32(&p.runs, 1)) <= p.failures {
+		if p.panics {
+			panic("run panicked")
+		}
+		return errors.New("run failed")
+	}
+	return p.AbstractMockedRunProvider.Run()
+}
+
+// Mocked run provider that stops running right after it started.
+type MockedStoppingRunProvider struct {
+	AbstractMockedRunProvider
+}
+
+func (p *MockedStoppingRunProvider) Run() error {
+	p.SetRunning(true)
+	p.SetRunning(false)
+	return nil
+}
+
+// Mocked run provider that runs until it is closed.
+type MockedBlockingRunProvider struct {
+	AbstractMockedRunProvider
+}
+
+func (p *MockedBlockingRunProvider) Run() error {
+	p.SetRunning(true)
+	<-p.Done()
+	return nil
+}
+
 // Mocked run provider that throws an error during startup.
-/*
 type MockedRunProviderRunErr struct {
 	AbstractMockedRunProvider
 }
 
+var errRun = errors.New("run failed")
+
 func (p *MockedRunProviderRunErr) Run() error {
-	return errors.New("run failed")
+	return errRun
+}
+
+// Mocked run provider that depends on other providers.
+type MockedDependentRunProvider struct {
+	AbstractMockedRunProvider
+	deps []provider.Provider
+}
+
+func (p *MockedDependentRunProvider) Dependencies() []provider.Provider {
+	return p.deps
 }
-*/
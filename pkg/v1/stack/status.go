@@ -0,0 +1,188 @@
+package stack
+
+import (
+	"encoding/json"
+	p "github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"time"
+)
+
+// Lifecycle state of a Provider within the Stack.
+type State string
+
+const (
+	StateInitializing State = "initializing"
+	StateInitialized  State = "initialized" // Also used for RunProviders that aren't (or no longer) running.
+	StateRunning      State = "running"
+	StateFailed       State = "failed"
+	StateClosed       State = "closed"
+)
+
+// Prometheus gauges, exposed by the Prometheus Provider.
+var (
+	upGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "provider_up",
+		Help: "Whether or not a provider is up: 1 if it is initialized (and running, for runnable providers), 0 otherwise.",
+	}, []string{"provider"})
+	initDurationGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "provider_init_duration_seconds",
+		Help: "Duration of the initialization of a provider.",
+	}, []string{"provider"})
+)
+
+func init() {
+	prometheus.MustRegister(upGauge, initDurationGauge)
+}
+
+// Status of a single Provider within the Stack.
+type ProviderStatus struct {
+	Name          string
+	State         State
+	Since         time.Time     // When the Provider entered its current state.
+	InitDuration  time.Duration // Duration of the initialization, once initialized.
+	CloseDuration time.Duration // Duration of closing, once closed.
+	RunDuration   time.Duration // Duration the RunProvider has been running for (or ran before it stopped).
+	Restarts      int           // Number of times the RunProvider was restarted.
+	Error         string        // Last error of the Provider, if any.
+
+	runnable  bool
+	runningAt time.Time
+}
+
+// Encodes durations as strings (e.g. "1.5s"), to be readable on the status endpoint.
+func (s ProviderStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Name          string    `json:"name"`
+		State         State     `json:"state"`
+		Since         time.Time `json:"since"`
+		InitDuration  string    `json:"init_duration,omitempty"`
+		CloseDuration string    `json:"close_duration,omitempty"`
+		RunDuration   string    `json:"run_duration,omitempty"`
+		Restarts      int       `json:"restarts,omitempty"`
+		Error         string    `json:"error,omitempty"`
+	}{
+		Name:          s.Name,
+		State:         s.State,
+		Since:         s.Since,
+		InitDuration:  formatDuration(s.InitDuration),
+		CloseDuration: formatDuration(s.CloseDuration),
+		RunDuration:   formatDuration(s.RunDuration),
+		Restarts:      s.Restarts,
+		Error:         s.Error,
+	})
+}
+
+func formatDuration(duration time.Duration) string {
+	if duration == 0 {
+		return ""
+	}
+	return duration.String()
+}
+
+// Returns the status of all Providers known to the Stack, in order of initialization.
+// Providers that were added but not initialized yet are left out.
+func (s *Stack) Status() []ProviderStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]ProviderStatus, 0, len(s.statusOrder))
+	for _, provider := range s.statusOrder {
+		status := *s.statuses[provider]
+		if status.State == StateRunning {
+			status.RunDuration = time.Since(status.runningAt)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// Returns an HTTP handler that publishes the status of all Providers as JSON.
+func (s *Stack) StatusHandler() http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(res).Encode(map[string]interface{}{"providers": s.Status()}); err != nil {
+			logrus.WithError(err).Warn("Error while writing Stack status")
+		}
+	})
+}
+
+// Updates the state of a Provider. Any error is stored as the last error of the Provider.
+func (s *Stack) setState(provider p.Provider, state State, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.updateState(provider, state, err)
+}
+
+// Updates the state of a Provider after it was closed (successfully or not), which took the given duration.
+func (s *Stack) setClosed(provider p.Provider, duration time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := StateClosed
+	if err != nil {
+		state = StateFailed
+	}
+	s.updateState(provider, state, err).CloseDuration = duration
+}
+
+// Updates the state of a Provider, creating its status if needed. Should only be called while holding the lock.
+func (s *Stack) updateState(provider p.Provider, state State, err error) *ProviderStatus {
+	status, ok := s.statuses[provider]
+	if !ok {
+		_, runnable := provider.(p.RunProvider)
+		status = &ProviderStatus{Name: p.Name(provider), runnable: runnable}
+		s.statuses[provider] = status
+		s.statusOrder = append(s.statusOrder, provider)
+	}
+
+	now := time.Now()
+	switch status.State {
+	case StateInitializing:
+		status.InitDuration = now.Sub(status.Since)
+		initDurationGauge.WithLabelValues(status.Name).Set(status.InitDuration.Seconds())
+	case StateRunning:
+		status.RunDuration = now.Sub(status.runningAt)
+	}
+	if state == StateRunning {
+		status.runningAt = now
+		status.RunDuration = 0
+	}
+	if err != nil {
+		status.Error = err.Error()
+	}
+
+	status.State = state
+	status.Since = now
+
+	up := state == StateRunning || (state == StateInitialized && !status.runnable)
+	if up {
+		upGauge.WithLabelValues(status.Name).Set(1)
+	} else {
+		upGauge.WithLabelValues(status.Name).Set(0)
+	}
+	return status
+}
+
+// Returns the current state of a Provider, or an empty state if it has none yet.
+func (s *Stack) state(provider p.Provider) State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if status, ok := s.statuses[provider]; ok {
+		return status.State
+	}
+	return ""
+}
+
+// Increments the number of restarts of a RunProvider.
+func (s *Stack) addRestart(provider p.Provider) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if status, ok := s.statuses[provider]; ok {
+		status.Restarts++
+	}
+}
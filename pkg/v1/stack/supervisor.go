@@ -0,0 +1,220 @@
+package stack
+
+import (
+	"errors"
+	"fmt"
+	p "github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/provider"
+	"github.com/prometheus/client_golang/prometheus"
+	"time"
+)
+
+// Restart policy of a supervised RunProvider.
+type RestartPolicy string
+
+const (
+	RestartNever     RestartPolicy = "never"      // Never restart the RunProvider.
+	RestartOnFailure RestartPolicy = "on-failure" // Only restart the RunProvider if its Run() method returned an error (or panicked).
+	RestartAlways    RestartPolicy = "always"     // Restart the RunProvider whenever its Run() method returns.
+)
+
+const (
+	defaultInitialBackoff = 1 * time.Second
+	defaultMaxBackoff     = 1 * time.Minute
+)
+
+// Returned (or recorded) when a RunProvider stopped running without error, while it wasn't supposed to.
+var ErrStopped = errors.New("provider stopped running")
+
+// Prometheus counters, exposed by the Prometheus Provider.
+var (
+	crashesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "provider_crashes_total",
+		Help: "Number of times the Run() method of a provider returned an error or panicked.",
+	}, []string{"provider"})
+	restartsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "provider_restarts_total",
+		Help: "Number of times a provider was restarted by the Stack.",
+	}, []string{"provider"})
+	stopsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "provider_stops_total",
+		Help: "Number of times a provider stopped running without error while the Stack was running.",
+	}, []string{"provider"})
+)
+
+func init() {
+	prometheus.MustRegister(crashesCounter, restartsCounter, stopsCounter)
+}
+
+// Supervision of a RunProvider, determining what the Stack does once its Run() method returns.
+// RunProviders that are restarted need to implement provider.Restartable.
+type Supervision struct {
+	Policy         RestartPolicy // When to restart the RunProvider.
+	MaxRestarts    int           // Maximum number of restarts before giving up. Zero means unlimited.
+	InitialBackoff time.Duration // Delay before the first restart, doubled after every restart. Defaults to 1 second.
+	MaxBackoff     time.Duration // Maximum delay between restarts. Defaults to 1 minute.
+	Critical       bool          // Whether or not to stop the whole Stack once the RunProvider fails or stops, and won't be restarted (anymore).
+}
+
+// Supervision of RunProviders without explicit supervision: a failing RunProvider stops the whole Stack.
+var DefaultSupervision = Supervision{
+	Policy:   RestartNever,
+	Critical: true,
+}
+
+// Returns true if the RunProvider should be restarted after its Run() method returned the given error.
+func (s Supervision) shouldRestart(err error) bool {
+	switch s.Policy {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return err != nil
+	default:
+		return false
+	}
+}
+
+// Returns the delay before the given restart (starting from 0), doubling the delay for every restart.
+func (s Supervision) backoff(restart int) time.Duration {
+	backoff, maxBackoff := s.InitialBackoff, s.MaxBackoff
+	if backoff <= 0 {
+		backoff = defaultInitialBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = defaultMaxBackoff
+	}
+
+	for i := 0; i < restart && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		return maxBackoff
+	}
+	return backoff
+}
+
+// Sets the supervision of a RunProvider, which applies once the Stack is running.
+// RunProviders without explicit supervision use the DefaultSupervision.
+// Returns an error if the supervision restarts a RunProvider that doesn't implement provider.Restartable.
+func (s *Stack) Supervise(provider p.RunProvider, supervision Supervision) error {
+	if supervision.Policy == RestartOnFailure || supervision.Policy == RestartAlways {
+		if _, ok := provider.(p.Restartable); !ok {
+			return fmt.Errorf("%s can't be restarted, it doesn't implement provider.Restartable", p.Name(provider))
+		}
+	}
+	s.supervisions[provider] = supervision
+	return nil
+}
+
+// Runs a RunProvider and restarts it according to its supervision, until the Stack stops or no more restarts are allowed.
+func (s *Stack) supervise(provider p.RunProvider) {
+	name := p.Name(provider)
+	supervision, ok := s.supervisions[provider]
+	if !ok {
+		supervision = DefaultSupervision
+	}
+
+	for restarts := 0; ; restarts++ {
+		err := s.runProvider(provider, restarts > 0)
+		stopping := s.isStopping()
+		stopped := false
+		if err != nil {
+			crashesCounter.WithLabelValues(name).Inc()
+			s.logger.WithError(err).Errorf("%s failed to run", name)
+			s.setState(provider, StateFailed, err)
+		} else if !stopping && stoppedRunning(provider) {
+			// While stopping, the RunProvider returns because it is being closed, which updates its state.
+			stopped = true
+			stopsCounter.WithLabelValues(name).Inc()
+			s.logger.Warnf("%s stopped running", name)
+			s.setState(provider, StateInitialized, nil)
+		}
+
+		if stopping {
+			if err != nil {
+				s.record(provider, err)
+			}
+			return
+		}
+		if !supervision.shouldRestart(err) {
+			if err != nil {
+				s.giveUp(provider, supervision, err)
+			} else if stopped {
+				s.giveUp(provider, supervision, ErrStopped)
+			}
+			return
+		}
+		if err == nil {
+			err = ErrStopped
+		}
+		if supervision.MaxRestarts > 0 && restarts >= supervision.MaxRestarts {
+			s.giveUp(provider, supervision, fmt.Errorf("giving up after %d restarts: %w", restarts, err))
+			return
+		}
+
+		backoff := supervision.backoff(restarts)
+		s.logger.WithError(err).Warnf("%s restarting in %s...", name, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-s.stopping:
+			return
+		}
+		restartsCounter.WithLabelValues(name).Inc()
+		s.addRestart(provider)
+	}
+}
+
+// Marks a RunProvider as failed, if it signals its readiness (see provider.AbstractRunProvider).
+func setFailed(provider p.RunProvider, err error) {
+	if failer, ok := provider.(interface{ SetFailed(err error) }); ok {
+		failer.SetFailed(err)
+	}
+}
+
+// Returns true if the RunProvider stopped running by itself after it started.
+// A RunProvider with a non-blocking Run() method can still be running after it returned,
+// and one that signals its readiness (see provider.Readiness) but never started (e.g. because it isn't enabled) didn't stop.
+func stoppedRunning(provider p.RunProvider) bool {
+	if provider.IsRunning() {
+		return false
+	}
+	if readiness, ok := provider.(p.Readiness); ok {
+		select {
+		case <-readiness.Ready():
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Runs a RunProvider once, turning panics into errors. A restarted RunProvider is reset first (see provider.Restartable).
+func (s *Stack) runProvider(provider p.RunProvider, restart bool) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	if restart {
+		if err := provider.(p.Restartable).Restart(); err != nil {
+			return fmt.Errorf("restart failed: %w", err)
+		}
+	}
+	s.setState(provider, StateRunning, nil)
+	s.logger.Debugf("%s launching...", p.Name(provider))
+	return provider.Run()
+}
+
+// Handles a RunProvider that failed or stopped, and won't be restarted (anymore).
+// Critical RunProviders stop the whole Stack, the failure of others is only recorded.
+// Either way, RunProviders waiting for it are notified of the failure.
+func (s *Stack) giveUp(provider p.RunProvider, supervision Supervision, err error) {
+	setFailed(provider, err)
+	if supervision.Critical {
+		s.fail(provider, err)
+		return
+	}
+
+	s.logger.WithError(err).Errorf("%s stopped permanently", p.Name(provider))
+	s.record(provider, err)
+}
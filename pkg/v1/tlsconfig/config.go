@@ -0,0 +1,50 @@
+package tlsconfig
+
+import (
+	"errors"
+	"fmt"
+	"github.azc.ext.hp.com/hp-business-platform/lib-provider-go/pkg/v1/config"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+// TLS configuration of a server.
+type Config struct {
+	config.Result
+
+	Enabled        bool          `env:"TLS_ENABLED" default:"false"`              // Whether or not to serve TLS.
+	CertFile       string        `env:"TLS_CERT_FILE"`                            // Path to the PEM encoded certificate (chain) of the server.
+	KeyFile        string        `env:"TLS_KEY_FILE"`                             // Path to the PEM encoded private key of the server.
+	ClientCAFile   string        `env:"TLS_CLIENT_CA_FILE"`                       // Path to the PEM encoded CA certificates used to verify clients. If set, clients need to present a valid certificate (mTLS).
+	ClientAuth     string        `env:"TLS_CLIENT_AUTH" default:"require"`        // Whether clients need to present a certificate when a client CA is set: require, or optional (only verified if presented).
+	ReloadInterval time.Duration `env:"TLS_RELOAD_INTERVAL" default:"30" min:"0"` // How often the certificate and key files are checked for changes. Zero disables reloading.
+}
+
+// Initializes the configuration from environment variables, using the given prefix (e.g. PROBES results in PROBES_TLS_ENABLED).
+func NewConfigFromEnv(prefix string) *Config {
+	c := &Config{}
+	_ = config.Load(prefix, c)
+
+	logrus.WithFields(config.Fields(c)).Debugf("%s TLS Config initialized", prefix)
+
+	return c
+}
+
+// Client authentication modes (see ClientAuth).
+const (
+	ClientAuthRequire  = "require"
+	ClientAuthOptional = "optional"
+)
+
+// Checks a certificate and key are configured when TLS is enabled, and the client authentication mode is supported.
+func (c *Config) Validate() error {
+	if c.Enabled && (c.CertFile == "" || c.KeyFile == "") {
+		return errors.New("TLS_CERT_FILE and TLS_KEY_FILE are required when TLS is enabled")
+	}
+	switch c.ClientAuth {
+	case "", ClientAuthRequire, ClientAuthOptional:
+	default:
+		return fmt.Errorf("unsupported TLS_CLIENT_AUTH %s, should be %s or %s", c.ClientAuth, ClientAuthRequire, ClientAuthOptional)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Creates the TLS configuration of a server, or returns nil if TLS isn't enabled.
+// The certificate is reloaded once its files change, so rotated certificates are picked up without a restart.
+// If a client CA file is configured, clients are required to present a certificate signed by one of its CAs,
+// or only have it verified if they present one when ClientAuth is optional.
+func (c *Config) ServerTLSConfig() (*tls.Config, error) {
+	if c == nil || !c.Enabled {
+		return nil, nil
+	}
+	if c.CertFile == "" || c.KeyFile == "" {
+		return nil, errors.New("TLS requires a certificate and key file")
+	}
+
+	reloader, err := NewCertReloader(c.CertFile, c.KeyFile, c.ReloadInterval)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}
+
+	if c.ClientCAFile != "" {
+		pool, err := loadCertPool(c.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		if c.ClientAuth == ClientAuthOptional {
+			tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+	}
+	return tlsConfig, nil
+}
+
+// Loads a pool of PEM encoded CA certificates.
+func loadCertPool(file string) (*x509.CertPool, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("could not read CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in CA file %s", file)
+	}
+	return pool, nil
+}
+
+// Certificate reloader.
+// Serves a certificate loaded from files, reloading it once the files were modified.
+// The files are checked for changes lazily (during handshakes), at most once per interval.
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+	checked time.Time
+}
+
+// Creates a certificate reloader, loading the certificate right away.
+// An interval of zero disables reloading.
+func NewCertReloader(certFile, keyFile string, interval time.Duration) (*CertReloader, error) {
+	r := &CertReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		interval: interval,
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Returns the current certificate, reloading it first if its files were modified. Usable as tls.Config.GetCertificate.
+// If reloading fails, the previous certificate keeps being served.
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.interval > 0 && time.Since(r.checked) >= r.interval {
+		r.checked = time.Now()
+		if modTime, err := r.lastModified(); err == nil && modTime.After(r.modTime) {
+			if err := r.load(); err != nil {
+				logrus.WithError(err).WithField("cert_file", r.certFile).Warn("Could not reload TLS certificate, keeping the previous one")
+			} else {
+				logrus.WithField("cert_file", r.certFile).Info("TLS certificate reloaded")
+			}
+		}
+	}
+	return r.cert, nil
+}
+
+// Returns the current certificate, like GetCertificate(). Usable as tls.Config.GetClientCertificate, for clients authenticating with a certificate.
+func (r *CertReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return r.GetCertificate(nil)
+}
+
+// Loads the certificate from its files. Should only be called while holding the lock (or while creating the reloader).
+func (r *CertReloader) load() error {
+	modTime, err := r.lastModified()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("could not load TLS certificate: %w", err)
+	}
+	r.cert = &cert
+	r.modTime = modTime
+	r.checked = time.Now()
+	return nil
+}
+
+// Returns the latest modification time of the certificate and key files.
+func (r *CertReloader) lastModified() (time.Time, error) {
+	var latest time.Time
+	for _, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return latest, fmt.Errorf("could not read TLS file: %w", err)
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}